@@ -0,0 +1,226 @@
+package cartridge
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"go-boy/hardware"
+)
+
+// Size of the image the sensor delivers, and where it lands in camera RAM as 16x14 tiles
+const (
+	CAMERA_WIDTH      = 128
+	CAMERA_HEIGHT     = 112
+	CAMERA_IMAGE_ADDR = 0x0100
+	CAMERA_RAM_SIZE   = 0x20000
+)
+
+// M64282FP registers mapped at 0xA000 when RAM bank 0x10 is selected, mirrored every 0x80 bytes
+const (
+	CAMERA_REG_CONTROL  = 0x00 // bit 0 starts a capture and reads 1 until it is done
+	CAMERA_REG_GAIN     = 0x01 // bits 0-4 gain, bits 5-6 edge direction VH, bit 7 N
+	CAMERA_REG_EXPOSURE = 0x02 // 16 bits, high byte first
+	CAMERA_REG_EDGE     = 0x04 // bits 0-2 Vref, bit 3 invert, bits 4-6 edge ratio, bit 7 edge extraction only
+	CAMERA_REG_ZERO     = 0x05 // zero point and offset calibration
+	CAMERA_REG_DITHER   = 0x06 // 4x4 matrix of 3 thresholds, 48 bytes
+	CAMERA_REGISTERS    = 0x36
+)
+
+// Exposure that maps full light to full scale at gain 0
+const CAMERA_EXPOSURE_REF = 0x1000
+
+// A capture takes a fixed time, more when the N bit is clear, plus time for each exposure step
+const (
+	CAMERA_CAPTURE_T_STATES  = 129792
+	CAMERA_N_T_STATES        = 2048
+	CAMERA_EXPOSURE_T_STATES = 64
+)
+
+var camera_edge_ratios = [8]float64{0.5, 0.75, 1, 1.25, 2, 3, 4, 5}
+
+// Camera is the Pocket Camera mapper, RAM bank 0x10 holds the sensor registers; its gain curve is approximate
+type Camera struct {
+	c          *Cartridge
+	ram_enable bool // gates writes, RAM is always readable
+	rom_bank   uint8
+	ram_bank   uint8
+	registers  [CAMERA_REGISTERS]byte
+	busy       uint32 // T-states until the capture in progress completes
+
+	sensor [CAMERA_HEIGHT][CAMERA_WIDTH]uint8 // luminance, 0 black to 255 white
+}
+
+func new_camera(c *Cartridge) hardware.Device {
+	c.RAM = make([]byte, CAMERA_RAM_SIZE) // the camera always has 128 KiB
+	return &Camera{c: c, rom_bank: 1}
+}
+
+func (m *Camera) SetImage(img image.Image) {
+	// Show the sensor a host image, converted to grayscale and stretched to 128x112
+	b := img.Bounds()
+	for y := 0; y < CAMERA_HEIGHT; y++ {
+		for x := 0; x < CAMERA_WIDTH; x++ {
+			px := b.Min.X + x*b.Dx()/CAMERA_WIDTH
+			py := b.Min.Y + y*b.Dy()/CAMERA_HEIGHT
+			m.sensor[y][x] = color.GrayModel.Convert(img.At(px, py)).(color.Gray).Y
+		}
+	}
+}
+
+func (m *Camera) LoadPNG(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return err
+	}
+	m.SetImage(img)
+	return nil
+}
+
+func (m *Camera) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.rom_bank))
+}
+
+func (m *Camera) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	if m.ram_bank&0x10 != 0 {
+		if addr&0x7f == CAMERA_REG_CONTROL {
+			return m.registers[CAMERA_REG_CONTROL]
+		}
+		return 0x00 // the other registers are write only
+	}
+	if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *Camera) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ram_enable = b&0x0f == 0x0a
+	case addr < 0x4000:
+		m.rom_bank = b & 0x3f // bank 0 can be selected
+	case addr < 0x6000:
+		m.ram_bank = b & 0x1f
+	case addr < 0x8000:
+	case m.ram_bank&0x10 != 0:
+		if reg := addr & 0x7f; reg < CAMERA_REGISTERS {
+			m.registers[reg] = b
+		}
+		if addr&0x7f == CAMERA_REG_CONTROL {
+			m.busy = 0 // writing 0 stops a capture
+			if b&0x01 != 0 {
+				m.busy = m.capture_t_states()
+			}
+		}
+	case !m.ram_enable:
+	default:
+		if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+			m.c.RAM[i] = b
+		}
+	}
+}
+
+func (m *Camera) capture_t_states() uint32 {
+	r := &m.registers
+	t := uint32(CAMERA_CAPTURE_T_STATES)
+	if r[CAMERA_REG_GAIN]&0x80 == 0 {
+		t += CAMERA_N_T_STATES
+	}
+	exposure := uint32(r[CAMERA_REG_EXPOSURE])<<8 | uint32(r[CAMERA_REG_EXPOSURE+1])
+	return t + exposure*CAMERA_EXPOSURE_T_STATES
+}
+
+func (m *Camera) Tick(t_states uint8) {
+	// Write the picture to RAM once the capture time is up, captures only complete while attached
+	if m.busy == 0 {
+		return
+	}
+	if m.busy > uint32(t_states) {
+		m.busy -= uint32(t_states)
+		return
+	}
+	m.busy = 0
+	m.capture()
+	m.registers[CAMERA_REG_CONTROL] &^= 0x01
+}
+
+func (m *Camera) voltage(x, y int) float64 {
+	// Sensor output before edge enhancement, in ADC units where 255 is full scale.
+	// Pixels outside the image repeat the edge so the border is not enhanced
+	x = min(max(x, 0), CAMERA_WIDTH-1)
+	y = min(max(y, 0), CAMERA_HEIGHT-1)
+	r := &m.registers
+	exposure := float64(uint16(r[CAMERA_REG_EXPOSURE])<<8 | uint16(r[CAMERA_REG_EXPOSURE+1]))
+	gain := math.Pow(10, 1.5*float64(r[CAMERA_REG_GAIN]&0x1f)/20) // approximately 1.5 dB a step
+	return float64(m.sensor[y][x]) * exposure / CAMERA_EXPOSURE_REF * gain
+}
+
+func (m *Camera) pixel(x, y int) float64 {
+	// Apply edge enhancement in the directions VH selects: 1 horizontal, 2 vertical, 3 both
+	r := &m.registers
+	v := m.voltage(x, y)
+	vh := r[CAMERA_REG_GAIN] >> 5 & 0x03
+	if vh != 0 {
+		edge := 0.0
+		if vh&0x01 != 0 {
+			edge += 2*v - m.voltage(x-1, y) - m.voltage(x+1, y)
+		}
+		if vh&0x02 != 0 {
+			edge += 2*v - m.voltage(x, y-1) - m.voltage(x, y+1)
+		}
+		edge *= camera_edge_ratios[r[CAMERA_REG_EDGE]>>4&0x07]
+		if r[CAMERA_REG_EDGE]&0x80 != 0 {
+			v = edge // extraction only
+		} else {
+			v += edge
+		}
+	}
+	if r[CAMERA_REG_EDGE]&0x08 != 0 {
+		v = 255 - v
+	}
+	return v
+}
+
+func (m *Camera) capture() {
+	// Dither the processed image with the threshold matrix into tiles in RAM bank 0,
+	// a value under the first threshold is black, at or over the third white
+	for y := 0; y < CAMERA_HEIGHT; y++ {
+		for x := 0; x < CAMERA_WIDTH; x++ {
+			v := m.pixel(x, y)
+			t := m.registers[CAMERA_REG_DITHER+((y&3)*4+(x&3))*3:]
+			shade := byte(0)
+			switch {
+			case v < float64(t[0]):
+				shade = 3
+			case v < float64(t[1]):
+				shade = 2
+			case v < float64(t[2]):
+				shade = 1
+			}
+			i := CAMERA_IMAGE_ADDR + ((y/8)*(CAMERA_WIDTH/8)+x/8)*16 + (y%8)*2
+			bit := byte(0x80) >> (x % 8)
+			m.c.RAM[i] &^= bit
+			m.c.RAM[i+1] &^= bit
+			if shade&0x01 != 0 {
+				m.c.RAM[i] |= bit
+			}
+			if shade&0x02 != 0 {
+				m.c.RAM[i+1] |= bit
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cartridge
+
+import (
+	"fmt"
+	"os"
+
+	"go-boy/hardware"
+)
+
+const (
+	ROM_BANK_SIZE = 0x4000
+	RAM_BANK_SIZE = 0x2000
+)
+
+type Cartridge struct {
+	Header *Header
+	ROM    []byte
+	RAM    []byte          // external RAM, nil if the cartridge has none
+	Mapper hardware.Device // decodes ROM0, ROMX and ERAM accesses
+}
+
+// Mapper constructors by mapper type, a type missing here cannot be loaded
+var mappers = map[MAPPER]func(c *Cartridge) hardware.Device{
+	MAPPER_NONE:   new_rom_only,
+	MAPPER_MBC1:   new_mbc1,
+	MAPPER_MBC2:   new_mbc2,
+	MAPPER_MMM01:  new_mmm01,
+	MAPPER_MBC3:   new_mbc3,
+	MAPPER_MBC5:   new_mbc5,
+	MAPPER_MBC7:   new_mbc7,
+	MAPPER_CAMERA: new_camera,
+	MAPPER_HUC3:   new_huc3,
+	MAPPER_HUC1:   new_huc1,
+}
+
+func Load(rom []byte) (*Cartridge, error) {
+	// Parse and validate a cartridge image. The header checksum is checked like the boot
+	// ROM does, the global checksum is not since hardware ignores it, see Header.Verify
+	h, err := ParseHeader(rom)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := cartridge_types[h.TypeCode]
+	if !ok {
+		return nil, fmt.Errorf("unknown cartridge type %02X", h.TypeCode)
+	}
+	new_mapper, ok := mappers[t.Mapper]
+	if !ok {
+		return nil, fmt.Errorf("cartridge type %02X (%v) is not supported", h.TypeCode, t)
+	}
+	if h.ROMSizeCode > 0x08 {
+		return nil, fmt.Errorf("unknown ROM size code %02X", h.ROMSizeCode)
+	}
+	if _, ok := ram_sizes[h.RAMSizeCode]; !ok {
+		return nil, fmt.Errorf("unknown RAM size code %02X", h.RAMSizeCode)
+	}
+	if len(rom) < h.ROMSize() {
+		return nil, fmt.Errorf("ROM is truncated, %d bytes but the header declares %d", len(rom), h.ROMSize())
+	}
+	if got := HeaderChecksum(header_image(rom)); got != h.HeaderChecksum {
+		return nil, &ChecksumError{Kind: "header", Want: uint16(h.HeaderChecksum), Got: uint16(got)}
+	}
+	rom = rom[:h.ROMSize()] // overdumps repeat the ROM or pad it, the mapper never sees past the declared size
+
+	c := &Cartridge{Header: h, ROM: rom}
+	if t.RAM && h.RAMSize() > 0 {
+		c.RAM = make([]byte, h.RAMSize())
+	}
+	c.Mapper = new_mapper(c)
+	return c, nil
+}
+
+func Open(path string) (*Cartridge, error) {
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Load(rom)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *Cartridge) ROMBanks() int {
+	return len(c.ROM) / ROM_BANK_SIZE
+}
+
+func (c *Cartridge) rom_wrap(bank int) int {
+	// Bank numbers past the end of ROM wrap around like unconnected address lines
+	return bank % c.ROMBanks()
+}
+
+func (c *Cartridge) rom_byte(bank int, addr uint16) byte {
+	// Read from a 16 KiB ROM bank
+	return c.ROM[c.rom_wrap(bank)*ROM_BANK_SIZE+int(addr&(ROM_BANK_SIZE-1))]
+}
+
+func (c *Cartridge) ram_index(bank int, addr uint16) int {
+	// Offset into RAM of an address in an 8 KiB RAM bank, wrapping like rom_byte, -1 without RAM
+	if len(c.RAM) == 0 {
+		return -1
+	}
+	return (bank*RAM_BANK_SIZE + int(addr&(RAM_BANK_SIZE-1))) % len(c.RAM)
+}
+
+func (c *Cartridge) Insert(bus *hardware.Bus) {
+	// Map the cartridge into both ROM windows and the external RAM window
+	bus.Map(hardware.REGION_ROM0, c.Mapper)
+	bus.Map(hardware.REGION_ROMX, c.Mapper)
+	bus.Map(hardware.REGION_ERAM, c.Mapper)
+}
+
+// 32 KiB of ROM mapped directly, optionally with up to 8 KiB of RAM
+type rom_only struct {
+	c *Cartridge
+}
+
+func new_rom_only(c *Cartridge) hardware.Device {
+	return &rom_only{c: c}
+}
+
+func (m *rom_only) Bank(addr uint16) int {
+	return int(addr >> 14 & 1)
+}
+
+func (m *rom_only) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.ROM[addr]
+	}
+	if i := int(addr - 0xA000); i < len(m.c.RAM) {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *rom_only) Write(addr uint16, b byte) {
+	if addr < 0x8000 {
+		return // ROM, and there are no mapper registers
+	}
+	if i := int(addr - 0xA000); i < len(m.c.RAM) {
+		m.c.RAM[i] = b
+	}
+}
@@ -0,0 +1,265 @@
+package cartridge
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-boy/hardware"
+)
+
+// Builds an image with the given header fields and valid checksums
+func testROM(size int, cart_type, rom_size, ram_size byte) []byte {
+	rom := make([]byte, size)
+	for i := range rom {
+		rom[i] = byte(i / ROM_BANK_SIZE) // every byte holds its bank number
+	}
+	copy(rom[HEADER_START:], make([]byte, HEADER_END-HEADER_START))
+	copy(rom[ADDR_TITLE:], "TESTCART")
+	rom[ADDR_TYPE] = cart_type
+	rom[ADDR_ROM_SIZE] = rom_size
+	rom[ADDR_RAM_SIZE] = ram_size
+	rom[ADDR_OLD_LICENSEE] = 0x01
+	fix_checksums(rom)
+	return rom
+}
+
+func load(t *testing.T, rom []byte) *Cartridge {
+	t.Helper()
+	c, err := Load(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func fix_checksums(rom []byte) {
+	rom[ADDR_HEADER_CHECKSUM] = HeaderChecksum(rom)
+	sum := GlobalChecksum(rom)
+	rom[ADDR_GLOBAL_CHECKSUM], rom[ADDR_GLOBAL_CHECKSUM+1] = byte(sum>>8), byte(sum)
+}
+
+func TestParseHeader(t *testing.T) {
+	t.Parallel()
+	rom := testROM(0x8000, 0x09, 0x00, 0x02)
+	h, err := ParseHeader(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Title != "TESTCART" || h.Manufacturer != "" || h.Type.String() != "ROM+RAM+BATTERY" || h.ROMSize() != 0x8000 || h.RAMSize() != 0x2000 {
+		t.Errorf("got %+v", *h)
+	}
+	if err := h.Verify(rom); err != nil {
+		t.Errorf("valid checksums reported %v", err)
+	}
+
+	// CGB cartridges end the title early for the manufacturer code and CGB flag
+	copy(rom[ADDR_TITLE:], "POKEMON_SLVAAXE\xc0")
+	copy(rom[ADDR_NEW_LICENSEE:], "01")
+	rom[ADDR_SGB_FLAG] = 0x03
+	rom[ADDR_OLD_LICENSEE] = 0x33
+	fix_checksums(rom)
+	h, _ = ParseHeader(rom)
+	if h.Title != "POKEMON_SLV" || h.Manufacturer != "AAXE" || !h.CGB() || !h.SGB() || h.NewLicensee != "01" {
+		t.Errorf("got title %q, manufacturer %q, CGB %v, SGB %v, licensee %q", h.Title, h.Manufacturer, h.CGB(), h.SGB(), h.NewLicensee)
+	}
+
+	rom[0x4000] ^= 0xff
+	var checksum *ChecksumError
+	if err := h.Verify(rom); !errors.As(err, &checksum) || checksum.Kind != "global" {
+		t.Errorf("corrupted ROM gave %v, wanted a global checksum error", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Parallel()
+	bad_checksum := testROM(0x8000, 0x00, 0x00, 0x00)
+	bad_checksum[ADDR_HEADER_CHECKSUM]++
+
+	for _, tc := range []struct {
+		rom  []byte
+		want string
+	}{
+		{make([]byte, 0x100), "too short to hold the cartridge header"},
+		{testROM(0x8000, 0x00, 0x01, 0x00)[:0x8000], "truncated, 32768 bytes but the header declares 65536"},
+		{testROM(0x8000, 0x04, 0x00, 0x00), "unknown cartridge type 04"},
+		{testROM(0x8000, 0xFD, 0x00, 0x00), "cartridge type FD (TAMA5) is not supported"},
+		{testROM(0x8000, 0x00, 0x09, 0x00), "unknown ROM size code 09"},
+		{testROM(0x8000, 0x00, 0x00, 0x07), "unknown RAM size code 07"},
+		{bad_checksum, "header checksum is"},
+	} {
+		if _, err := Load(tc.rom); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("got error %v, wanted %q", err, tc.want)
+		}
+	}
+}
+
+func TestLoadOverdump(t *testing.T) {
+	t.Parallel()
+	// a file larger than the header declares loads the declared size
+	c := load(t, testROM(0x10000, 0x00, 0x00, 0x00))
+	if len(c.ROM) != 0x8000 || c.ROMBanks() != 2 {
+		t.Fatalf("loaded %d bytes in %d banks, wanted 32768 in 2", len(c.ROM), c.ROMBanks())
+	}
+}
+
+func TestROMOnly(t *testing.T) {
+	t.Parallel()
+	c, err := Load(testROM(0x8000, 0x08, 0x00, 0x02))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := hardware.NewBus()
+	c.Insert(bus)
+
+	bus.Write(0x4000, 0x42) // ROM ignores writes
+	bus.Write(0xa000, 0x24)
+	if bus.Read(0x4000) != 0x01 || bus.Read(0x0000) != 0x00 || bus.Read(0xa000) != 0x24 || c.RAM[0] != 0x24 {
+		t.Errorf("[0000] %02x, [4000] %02x, [a000] %02x", bus.Read(0x0000), bus.Read(0x4000), bus.Read(0xa000))
+	}
+
+	// without RAM the external RAM window reads as open bus
+	c = load(t, testROM(0x8000, 0x00, 0x00, 0x00))
+	c.Insert(bus)
+	if bus.Read(0xa000) != 0xff || c.RAM != nil {
+		t.Errorf("[a000] is %02x without RAM", bus.Read(0xa000))
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), SavePath("game.gb"))
+	rom := testROM(0x8000, 0x09, 0x00, 0x02)
+	if _, err := OpenSave(load(t, testROM(0x8000, 0x08, 0x00, 0x02)), path); err == nil {
+		t.Errorf("opened a save for a cartridge without a battery")
+	}
+
+	// writes are flushed once RAM has been left alone for a while
+	c := load(t, rom)
+	s, err := OpenSave(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := func(t_states int) {
+		for ; t_states > 0; t_states -= 4 {
+			s.Tick(4)
+		}
+	}
+	c.Mapper.Write(0xa000, 0x12)
+	tick(SAVE_CHECK_T_STATES)
+	c.Mapper.Write(0xbfff, 0x34)
+	tick(SAVE_SETTLE_T_STATES)
+	if _, err := os.Stat(path); err == nil {
+		t.Errorf("save written before RAM settled")
+	}
+	tick(2 * SAVE_CHECK_T_STATES)
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) != 0x2000 || data[0] != 0x12 || data[0x1fff] != 0x34 {
+		t.Fatalf("save is %d bytes (%v), wanted a raw dump of RAM", len(data), err)
+	}
+
+	c.Mapper.Write(0xa001, 0x56)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	c2 := load(t, rom)
+	if _, err := OpenSave(c2, path); err != nil || !bytes.Equal(c2.RAM, c.RAM) {
+		t.Errorf("reloaded RAM differs (%v)", err)
+	}
+	if err := c2.LoadSaveData(make([]byte, 0x2001)); err == nil {
+		t.Errorf("loaded save data with a trailer for a cartridge without a clock")
+	}
+}
+
+func TestSaveTrailers(t *testing.T) {
+	t.Parallel()
+	c := load(t, testROM(0x8000, 0x10, 0x00, 0x02))
+	clock := &ManualClock{T: time.Unix(1_700_000_000, 0)}
+	rtc := c.Mapper.(*MBC3).RTC
+	rtc.SetClock(clock)
+	rtc.Write(RTC_H, 5)
+	c.RAM[0] = 0x77
+	data, err := c.SaveData()
+	if err != nil || len(data) != 0x2000+RTC_TRAILER_SIZE {
+		t.Fatalf("MBC3 save is %d bytes (%v), wanted RAM and the RTC trailer", len(data), err)
+	}
+	// reloaded an hour and a half later
+	c2 := load(t, c.ROM)
+	rtc2 := c2.Mapper.(*MBC3).RTC
+	rtc2.SetClock(&ManualClock{T: clock.T.Add(90 * time.Minute)})
+	if err := c2.LoadSaveData(data); err != nil {
+		t.Fatal(err)
+	}
+	if want := [RTC_REGISTERS]byte{0, 30, 6, 0, 0}; c2.RAM[0] != 0x77 || rtc2.Registers != want {
+		t.Errorf("restored RAM %02x and RTC % x, wanted 77 and % x", c2.RAM[0], rtc2.Registers, want)
+	}
+	if err := c2.LoadSaveData(data[:0x2000]); err != nil {
+		t.Errorf("save without the RTC trailer gave %v", err)
+	}
+	if err := c2.LoadSaveData(data[:0x2000+10]); err == nil {
+		t.Errorf("loaded a truncated RTC trailer")
+	}
+
+	c = load(t, testROM(0x200000, 0xfe, 0x06, 0x03))
+	if data, _ := c.SaveData(); len(data) != 0x8000+HUC3_TRAILER_SIZE {
+		t.Errorf("HuC3 save is %d bytes, wanted RAM and the clock trailer", len(data))
+	}
+	c = load(t, testROM(0x100000, 0x22, 0x05, 0x00))
+	if data, _ := c.SaveData(); len(data) != MBC7_EEPROM_SIZE {
+		t.Errorf("MBC7 save is %d bytes, wanted the EEPROM", len(data))
+	}
+}
+
+func TestInsertUnderBootROM(t *testing.T) {
+	t.Parallel()
+	// the boot ROM ends by writing 1 to 0xFF50 at 0x00FC, falling through to the cartridge at 0x0100
+	image := make([]byte, hardware.BOOT_ROM_SIZE_DMG)
+	copy(image[0xfc:], []byte{0x3e, 0x01, 0xe0, 0x50}) // ld A,0x01, ldh [a8],A
+	rom := testROM(0x10000, 0x01, 0x01, 0x00)
+	rom[0x0000] = 0x99
+
+	for _, boot_first := range []bool{true, false} {
+		cpu := hardware.NewCPU()
+		c := load(t, rom)
+		if !boot_first {
+			c.Insert(cpu.Bus)
+		}
+		boot, err := hardware.LoadBootROM(cpu, image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if boot_first {
+			c.Insert(cpu.Bus)
+		}
+		cpu.Bus.Write(0x2000, 0x03) // mapper registers respond under the boot ROM
+		if cpu.Bus.Read(0x0000) != 0x00 || cpu.Bus.Read(0x4000) != 0x03 {
+			t.Errorf("boot ROM first %v: [0000] %02x, [4000] %02x, wanted 00 from the boot ROM and bank 3", boot_first, cpu.Bus.Read(0x0000), cpu.Bus.Read(0x4000))
+		}
+		if err := cpu.RunUntil(0x0100); err != nil {
+			t.Fatal(err)
+		}
+		if boot.Mapped || cpu.Bus.Read(0x0000) != 0x99 || cpu.Bus.Read(0x4000) != 0x03 {
+			t.Errorf("boot ROM first %v: [0000] %02x after FF50, wanted 99 from the cartridge", boot_first, cpu.Bus.Read(0x0000))
+		}
+	}
+}
+
+func TestFaultBank(t *testing.T) {
+	t.Parallel()
+	// an illegal opcode in a switched bank is reported with that bank
+	rom := testROM(0x20000, 0x19, 0x02, 0x00) // MBC5, 8 banks
+	rom[5*ROM_BANK_SIZE+0x0123] = 0xdd
+	cpu := hardware.NewCPU()
+	load(t, rom).Insert(cpu.Bus)
+	cpu.Bus.Write(0x2000, 0x05)
+	cpu.PC = 0x4123
+	err := cpu.Step()
+	var fault *hardware.Fault
+	if !errors.As(err, &fault) || fault.Bank != 5 {
+		t.Fatalf("got %v, wanted a fault in bank 05", err)
+	}
+}
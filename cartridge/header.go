@@ -0,0 +1,273 @@
+package cartridge
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	HEADER_START = 0x0100
+	HEADER_END   = 0x0150 // first byte after the header
+)
+
+const (
+	ADDR_TITLE           = 0x0134
+	ADDR_MANUFACTURER    = 0x013F
+	ADDR_CGB_FLAG        = 0x0143
+	ADDR_NEW_LICENSEE    = 0x0144
+	ADDR_SGB_FLAG        = 0x0146
+	ADDR_TYPE            = 0x0147
+	ADDR_ROM_SIZE        = 0x0148
+	ADDR_RAM_SIZE        = 0x0149
+	ADDR_DESTINATION     = 0x014A
+	ADDR_OLD_LICENSEE    = 0x014B
+	ADDR_VERSION         = 0x014C
+	ADDR_HEADER_CHECKSUM = 0x014D
+	ADDR_GLOBAL_CHECKSUM = 0x014E
+)
+
+type MAPPER uint8
+
+const (
+	MAPPER_NONE MAPPER = iota
+	MAPPER_MBC1
+	MAPPER_MBC2
+	MAPPER_MMM01
+	MAPPER_MBC3
+	MAPPER_MBC5
+	MAPPER_MBC6
+	MAPPER_MBC7
+	MAPPER_CAMERA
+	MAPPER_TAMA5
+	MAPPER_HUC3
+	MAPPER_HUC1
+)
+
+var mapper_names = []string{"ROM", "MBC1", "MBC2", "MMM01", "MBC3", "MBC5", "MBC6", "MBC7", "POCKET CAMERA", "TAMA5", "HuC3", "HuC1"}
+
+func (m MAPPER) String() string {
+	if int(m) < len(mapper_names) {
+		return mapper_names[m]
+	}
+	return fmt.Sprintf("MAPPER(%d)", m)
+}
+
+type CARTRIDGE_TYPE struct {
+	Mapper  MAPPER
+	RAM     bool // external RAM, MBC2 and MBC7 have theirs built into the mapper
+	Battery bool // RAM and clock contents survive power off
+	Timer   bool // real time clock
+	Rumble  bool
+	Sensor  bool // accelerometer
+}
+
+func (t CARTRIDGE_TYPE) String() string {
+	parts := []string{t.Mapper.String()}
+	for _, p := range []struct {
+		set  bool
+		name string
+	}{{t.Timer, "TIMER"}, {t.Sensor, "SENSOR"}, {t.Rumble, "RUMBLE"}, {t.RAM, "RAM"}, {t.Battery, "BATTERY"}} {
+		if p.set {
+			parts = append(parts, p.name)
+		}
+	}
+	if len(parts) == 1 && t.Mapper == MAPPER_NONE {
+		return "ROM ONLY"
+	}
+	return strings.Join(parts, "+")
+}
+
+// Cartridge type byte at 0x0147
+var cartridge_types = map[byte]CARTRIDGE_TYPE{
+	0x00: {Mapper: MAPPER_NONE},
+	0x01: {Mapper: MAPPER_MBC1},
+	0x02: {Mapper: MAPPER_MBC1, RAM: true},
+	0x03: {Mapper: MAPPER_MBC1, RAM: true, Battery: true},
+	0x05: {Mapper: MAPPER_MBC2},
+	0x06: {Mapper: MAPPER_MBC2, Battery: true},
+	0x08: {Mapper: MAPPER_NONE, RAM: true},
+	0x09: {Mapper: MAPPER_NONE, RAM: true, Battery: true},
+	0x0B: {Mapper: MAPPER_MMM01},
+	0x0C: {Mapper: MAPPER_MMM01, RAM: true},
+	0x0D: {Mapper: MAPPER_MMM01, RAM: true, Battery: true},
+	0x0F: {Mapper: MAPPER_MBC3, Timer: true, Battery: true},
+	0x10: {Mapper: MAPPER_MBC3, Timer: true, RAM: true, Battery: true},
+	0x11: {Mapper: MAPPER_MBC3},
+	0x12: {Mapper: MAPPER_MBC3, RAM: true},
+	0x13: {Mapper: MAPPER_MBC3, RAM: true, Battery: true},
+	0x19: {Mapper: MAPPER_MBC5},
+	0x1A: {Mapper: MAPPER_MBC5, RAM: true},
+	0x1B: {Mapper: MAPPER_MBC5, RAM: true, Battery: true},
+	0x1C: {Mapper: MAPPER_MBC5, Rumble: true},
+	0x1D: {Mapper: MAPPER_MBC5, Rumble: true, RAM: true},
+	0x1E: {Mapper: MAPPER_MBC5, Rumble: true, RAM: true, Battery: true},
+	0x20: {Mapper: MAPPER_MBC6},
+	0x22: {Mapper: MAPPER_MBC7, Sensor: true, Rumble: true, RAM: true, Battery: true},
+	0xFC: {Mapper: MAPPER_CAMERA, RAM: true, Battery: true},
+	0xFD: {Mapper: MAPPER_TAMA5},
+	0xFE: {Mapper: MAPPER_HUC3, Timer: true, RAM: true, Battery: true},
+	0xFF: {Mapper: MAPPER_HUC1, RAM: true, Battery: true},
+}
+
+// External RAM size for the code at 0x0149, code 1 is unused
+var ram_sizes = map[byte]int{0x00: 0, 0x02: 8 * 1024, 0x03: 32 * 1024, 0x04: 128 * 1024, 0x05: 64 * 1024}
+
+var destinations = []string{"Japan", "Overseas"}
+
+type Header struct {
+	Title          string
+	Manufacturer   string // 4 character code on later cartridges, empty before
+	CGBFlag        byte   // 0x80 CGB enhanced, 0xC0 CGB only
+	NewLicensee    string // 2 character code, used when OldLicensee is 0x33
+	SGBFlag        byte   // 0x03 if SGB functions are supported
+	TypeCode       byte
+	Type           CARTRIDGE_TYPE
+	ROMSizeCode    byte
+	RAMSizeCode    byte
+	Destination    byte
+	OldLicensee    byte
+	Version        byte
+	HeaderChecksum byte
+	GlobalChecksum uint16
+}
+
+func (h *Header) ROMSize() int {
+	// 32 KiB doubled for every step of the size code
+	return 0x8000 << h.ROMSizeCode
+}
+
+func (h *Header) RAMSize() int {
+	return ram_sizes[h.RAMSizeCode]
+}
+
+func (h *Header) CGB() bool {
+	return h.CGBFlag&0x80 != 0
+}
+
+func (h *Header) SGB() bool {
+	return h.SGBFlag == 0x03
+}
+
+func (h *Header) DestinationName() string {
+	if int(h.Destination) < len(destinations) {
+		return destinations[h.Destination]
+	}
+	return fmt.Sprintf("unknown (%02X)", h.Destination)
+}
+
+func printable(b []byte) string {
+	// Trim a fixed width ASCII field at the first non printable byte
+	for i, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+func is_manufacturer(b []byte) bool {
+	for _, c := range b {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func HeaderChecksum(rom []byte) byte {
+	// Checksum of 0x0134-0x014C verified by the boot ROM
+	x := byte(0)
+	for _, b := range rom[ADDR_TITLE:ADDR_HEADER_CHECKSUM] {
+		x = x - b - 1
+	}
+	return x
+}
+
+func GlobalChecksum(rom []byte) uint16 {
+	// Sum of every byte in the ROM except the checksum itself, not verified by hardware
+	skip := len(rom) - len(header_image(rom)) + ADDR_GLOBAL_CHECKSUM
+	sum := uint16(0)
+	for i, b := range rom {
+		if i != skip && i != skip+1 {
+			sum += uint16(b)
+		}
+	}
+	return sum
+}
+
+type ChecksumError struct {
+	Kind string // "header" or "global"
+	Want uint16 // value stored in the header
+	Got  uint16 // value computed from the ROM
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Kind == "header" {
+		return fmt.Sprintf("header checksum is %02X but the header says %02X", e.Got, e.Want)
+	}
+	return fmt.Sprintf("%s checksum is %04X but the header says %04X", e.Kind, e.Got, e.Want)
+}
+
+// MMM01 multicarts power up with the menu and its header in the last 32 KiB
+const MMM01_MENU_SIZE = 0x8000
+
+func header_image(rom []byte) []byte {
+	// Part of the ROM the boot ROM sees at power on, the end of an MMM01 image or the start of any other
+	if len(rom) < 2*MMM01_MENU_SIZE {
+		return rom
+	}
+	menu := rom[len(rom)-MMM01_MENU_SIZE:]
+	if t, ok := cartridge_types[menu[ADDR_TYPE]]; ok && t.Mapper == MAPPER_MMM01 && HeaderChecksum(menu) == menu[ADDR_HEADER_CHECKSUM] {
+		return menu
+	}
+	return rom
+}
+
+func ParseHeader(rom []byte) (*Header, error) {
+	// Decode the header at 0x0100-0x014F without validating it, MMM01 headers are found
+	// the same distance from the start of the last 32 KiB
+	if len(rom) < HEADER_END {
+		return nil, fmt.Errorf("ROM is %d bytes, too short to hold the cartridge header at %04X-%04X", len(rom), HEADER_START, HEADER_END-1)
+	}
+	rom = header_image(rom)
+	h := &Header{
+		CGBFlag:        rom[ADDR_CGB_FLAG],
+		NewLicensee:    printable(rom[ADDR_NEW_LICENSEE : ADDR_NEW_LICENSEE+2]),
+		SGBFlag:        rom[ADDR_SGB_FLAG],
+		TypeCode:       rom[ADDR_TYPE],
+		Type:           cartridge_types[rom[ADDR_TYPE]],
+		ROMSizeCode:    rom[ADDR_ROM_SIZE],
+		RAMSizeCode:    rom[ADDR_RAM_SIZE],
+		Destination:    rom[ADDR_DESTINATION],
+		OldLicensee:    rom[ADDR_OLD_LICENSEE],
+		Version:        rom[ADDR_VERSION],
+		HeaderChecksum: rom[ADDR_HEADER_CHECKSUM],
+		GlobalChecksum: uint16(rom[ADDR_GLOBAL_CHECKSUM])<<8 | uint16(rom[ADDR_GLOBAL_CHECKSUM+1]),
+	}
+
+	// CGB era cartridges shortened the title to make room for the manufacturer code and CGB flag
+	title := rom[ADDR_TITLE:ADDR_NEW_LICENSEE]
+	if h.CGB() {
+		title = rom[ADDR_TITLE:ADDR_CGB_FLAG]
+		if code := rom[ADDR_MANUFACTURER:ADDR_CGB_FLAG]; is_manufacturer(code) {
+			title = rom[ADDR_TITLE:ADDR_MANUFACTURER]
+			h.Manufacturer = string(code)
+		}
+	}
+	h.Title = strings.TrimRight(printable(title), " ")
+	return h, nil
+}
+
+func (h *Header) Verify(rom []byte) error {
+	// Check both checksums, the header checksum is the one the boot ROM refuses to start without
+	if got := HeaderChecksum(header_image(rom)); got != h.HeaderChecksum {
+		return &ChecksumError{Kind: "header", Want: uint16(h.HeaderChecksum), Got: uint16(got)}
+	}
+	if len(rom) > h.ROMSize() {
+		rom = rom[:h.ROMSize()] // as Load does for an overdump
+	}
+	if got := GlobalChecksum(rom); got != h.GlobalChecksum {
+		return &ChecksumError{Kind: "global", Want: h.GlobalChecksum, Got: got}
+	}
+	return nil
+}
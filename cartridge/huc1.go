@@ -0,0 +1,79 @@
+package cartridge
+
+import "go-boy/hardware"
+
+// HuC1 banks ROM and RAM like MBC1, writing 0x0E to 0x0000-0x1FFF swaps RAM for the infrared transceiver
+type HuC1 struct {
+	c        *Cartridge
+	ir_mode  bool
+	rom_bank uint8 // 6 bits, 0 reads as 1
+	ram_bank uint8
+
+	IR hardware.Infrared // a Loopback unless replaced
+}
+
+func new_huc1(c *Cartridge) hardware.Device {
+	return &HuC1{c: c, rom_bank: 1, IR: &hardware.Loopback{}}
+}
+
+func (m *HuC1) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.rom_bank))
+}
+
+func (m *HuC1) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	if m.ir_mode {
+		return ir_read(m.IR)
+	}
+	if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *HuC1) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ir_mode = b == 0x0e
+	case addr < 0x4000:
+		m.rom_bank = b & 0x3f
+		if m.rom_bank == 0 {
+			m.rom_bank = 1
+		}
+	case addr < 0x6000:
+		m.ram_bank = b & 0x03
+	case addr < 0x8000:
+	case m.ir_mode:
+		m.IR.Emit(b&0x01 != 0)
+	default:
+		if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+			m.c.RAM[i] = b
+		}
+	}
+}
+
+func ir_read(ir hardware.Infrared) byte {
+	// The Hudson mappers read 0xC1 while light is received and 0xC0 otherwise
+	if ir.Receive() {
+		return 0xc1
+	}
+	return 0xc0
+}
+
+func (c *Cartridge) SetInfrared(link hardware.Infrared) bool {
+	// Put the HuC1 or HuC3 transceiver on link, false for cartridges without one
+	switch m := c.Mapper.(type) {
+	case *HuC1:
+		m.IR = link
+	case *HuC3:
+		m.IR = link
+	default:
+		return false
+	}
+	return true
+}
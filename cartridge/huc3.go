@@ -0,0 +1,233 @@
+package cartridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go-boy/hardware"
+)
+
+// Values written to 0x0000-0x1FFF select what the RAM window holds
+const (
+	HUC3_RAM_READ  = 0x0 // RAM, read only
+	HUC3_RAM       = 0xA // RAM
+	HUC3_COMMAND   = 0xB // writes queue a command for the clock chip
+	HUC3_RESPONSE  = 0xC // reads return the result of the last command
+	HUC3_SEMAPHORE = 0xD // writing bit 0 clear runs the command, bit 0 reads set when done
+	HUC3_IR        = 0xE // infrared transceiver
+)
+
+// Commands in the high nibble of a command byte, the low nibble is the argument
+const (
+	HUC3_CMD_READ      = 0x1 // respond with the nibble at the address and increment it
+	HUC3_CMD_WRITE     = 0x3 // store the argument at the address and increment it
+	HUC3_CMD_ADDR_LOW  = 0x4
+	HUC3_CMD_ADDR_HIGH = 0x5
+	HUC3_CMD_EXTENDED  = 0x6 // the argument selects one of the HUC3_EXT commands
+)
+
+const (
+	HUC3_EXT_GET_TIME = 0x0 // copy minutes and days into nibbles 0x00-0x05
+	HUC3_EXT_SET_TIME = 0x1 // load minutes and days from nibbles 0x00-0x05
+	HUC3_EXT_TONE     = 0xE // play the tone selected by nibble 0x27
+)
+
+const HUC3_TONE_ADDR = 0x27
+
+type ToneEvent struct {
+	Tone byte      // tone number, 0-15
+	Time time.Time // when the game started it, from the clock's Clock
+}
+
+// HuC3 banks ROM and RAM, its RAM window can also hold the clock chip's command interface or infrared
+type HuC3 struct {
+	c        *Cartridge
+	mode     byte
+	rom_bank uint8
+	ram_bank uint8
+	command  byte
+	response byte
+	address  byte // nibble of clock memory the next read or write command uses
+
+	RTC    *HuC3RTC
+	IR     hardware.Infrared // a Loopback unless replaced
+	OnTone func(ToneEvent)
+}
+
+func new_huc3(c *Cartridge) hardware.Device {
+	return &HuC3{c: c, rom_bank: 1, RTC: NewHuC3RTC(SystemClock{}), IR: &hardware.Loopback{}}
+}
+
+func (m *HuC3) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.rom_bank))
+}
+
+func (m *HuC3) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	switch m.mode {
+	case HUC3_RAM_READ, HUC3_RAM:
+		if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+			return m.c.RAM[i]
+		}
+	case HUC3_RESPONSE:
+		return m.response
+	case HUC3_SEMAPHORE:
+		return 0x01 // commands complete instantly
+	case HUC3_IR:
+		return ir_read(m.IR)
+	}
+	return 0xff
+}
+
+func (m *HuC3) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.mode = b & 0x0f
+	case addr < 0x4000:
+		m.rom_bank = b & 0x7f
+		if m.rom_bank == 0 {
+			m.rom_bank = 1
+		}
+	case addr < 0x6000:
+		m.ram_bank = b & 0x0f
+	case addr < 0x8000:
+	case m.mode == HUC3_RAM:
+		if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+			m.c.RAM[i] = b
+		}
+	case m.mode == HUC3_COMMAND:
+		m.command = b
+	case m.mode == HUC3_SEMAPHORE:
+		if b&0x01 == 0 {
+			m.run()
+		}
+	case m.mode == HUC3_IR:
+		m.IR.Emit(b&0x01 != 0)
+	}
+}
+
+func (m *HuC3) run() {
+	// Execute the queued command, the response echoes it with the result in the low nibble
+	arg := m.command & 0x0f
+	r := m.RTC
+	m.response = m.command
+	switch m.command >> 4 {
+	case HUC3_CMD_READ:
+		m.response = m.command&0xf0 | r.Memory[m.address]
+		m.address++
+	case HUC3_CMD_WRITE:
+		r.Memory[m.address] = arg
+		m.address++
+	case HUC3_CMD_ADDR_LOW:
+		m.address = m.address&0xf0 | arg
+	case HUC3_CMD_ADDR_HIGH:
+		m.address = m.address&0x0f | arg<<4
+	case HUC3_CMD_EXTENDED:
+		switch arg {
+		case HUC3_EXT_GET_TIME:
+			r.update()
+			put_nibbles(r.Memory[0:3], r.Minutes)
+			put_nibbles(r.Memory[3:6], r.Days)
+		case HUC3_EXT_SET_TIME:
+			r.Set(get_nibbles(r.Memory[0:3]), get_nibbles(r.Memory[3:6]))
+		case HUC3_EXT_TONE:
+			if m.OnTone != nil {
+				m.OnTone(ToneEvent{Tone: r.Memory[HUC3_TONE_ADDR], Time: r.clock.Now()})
+			}
+		}
+	}
+}
+
+func put_nibbles(dst []byte, v uint16) {
+	// Store v across nibbles, least significant first
+	for i := range dst {
+		dst[i] = byte(v>>(4*i)) & 0x0f
+	}
+}
+
+func get_nibbles(src []byte) uint16 {
+	v := uint16(0)
+	for i := range src {
+		v |= uint16(src[i]&0x0f) << (4 * i)
+	}
+	return v
+}
+
+const (
+	HUC3_MINUTES_PER_DAY = 24 * 60
+	HUC3_DAYS            = 0x1000 // the day counter is 12 bits
+)
+
+// Size of the HuC3 save RAM trailer as SameBoy lays it out, a UNIX time, the counters and the alarm
+const HUC3_TRAILER_SIZE = 17
+
+// HuC3RTC counts minutes and days and holds the 256 nibbles of memory the clock commands use
+type HuC3RTC struct {
+	Minutes uint16 // 0-1439
+	Days    uint16 // 0-4095
+	Memory  [256]byte
+
+	alarm [5]byte // alarm words of the trailer, kept so they survive a save
+	clock Clock
+	last  time.Time // host time the counters were last brought up to date
+}
+
+func NewHuC3RTC(clock Clock) *HuC3RTC {
+	return &HuC3RTC{clock: clock, last: clock.Now()}
+}
+
+func (r *HuC3RTC) SetClock(clock Clock) {
+	// Switch clock source, time elapsed on the old clock is kept
+	r.update()
+	r.clock = clock
+	r.last = clock.Now()
+}
+
+func (r *HuC3RTC) Set(minutes, days uint16) {
+	// Set the counters, restarting the current minute
+	r.Minutes = minutes % HUC3_MINUTES_PER_DAY
+	r.Days = days % HUC3_DAYS
+	r.last = r.clock.Now()
+}
+
+func (r *HuC3RTC) update() {
+	// Count the whole minutes elapsed on the clock since the last update
+	elapsed := int64(r.clock.Now().Sub(r.last) / time.Minute)
+	if elapsed <= 0 {
+		return
+	}
+	r.last = r.last.Add(time.Duration(elapsed) * time.Minute)
+	total := int64(r.Minutes) + elapsed
+	r.Minutes = uint16(total % HUC3_MINUTES_PER_DAY)
+	r.Days = uint16((int64(r.Days) + total/HUC3_MINUTES_PER_DAY) % HUC3_DAYS)
+}
+
+func (r *HuC3RTC) MarshalBinary() ([]byte, error) {
+	// Encode the 17 byte save RAM trailer
+	r.update()
+	b := make([]byte, HUC3_TRAILER_SIZE)
+	binary.LittleEndian.PutUint64(b, uint64(r.last.Unix()))
+	binary.LittleEndian.PutUint16(b[8:], r.Minutes)
+	binary.LittleEndian.PutUint16(b[10:], r.Days)
+	copy(b[12:], r.alarm[:])
+	return b, nil
+}
+
+func (r *HuC3RTC) UnmarshalBinary(b []byte) error {
+	// Decode a save RAM trailer and count the time elapsed since it was written
+	if len(b) != HUC3_TRAILER_SIZE {
+		return fmt.Errorf("HuC3 clock trailer is %d bytes, expected %d", len(b), HUC3_TRAILER_SIZE)
+	}
+	r.last = time.Unix(int64(binary.LittleEndian.Uint64(b)), 0)
+	r.Minutes = binary.LittleEndian.Uint16(b[8:]) % HUC3_MINUTES_PER_DAY
+	r.Days = binary.LittleEndian.Uint16(b[10:]) % HUC3_DAYS
+	copy(r.alarm[:], b[12:])
+	r.update()
+	return nil
+}
@@ -0,0 +1,674 @@
+package cartridge
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-boy/hardware"
+)
+
+func insert(t *testing.T, rom []byte) (*Cartridge, *hardware.Bus) {
+	t.Helper()
+	c, err := Load(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := hardware.NewBus()
+	c.Insert(bus)
+	return c, bus
+}
+
+type bankCheck struct {
+	addr uint16
+	want byte // bank number stored in every byte of testROM
+}
+
+func checkBanks(t *testing.T, bus *hardware.Bus, what string, checks ...bankCheck) {
+	t.Helper()
+	for _, c := range checks {
+		if got := bus.Read(c.addr); got != c.want {
+			t.Errorf("%s: [%04x] is bank %02x, wanted %02x", what, c.addr, got, c.want)
+		}
+		if m, ok := bus.Device(hardware.Decode(c.addr)).(hardware.Banked); !ok {
+			t.Errorf("%s: %T does not report its banks", what, bus.Device(hardware.Decode(c.addr)))
+		} else if got := byte(m.Bank(c.addr)); got != c.want {
+			t.Errorf("%s: Bank(%04x) is %02x, wanted %02x", what, c.addr, got, c.want)
+		}
+	}
+}
+
+func TestMBC1(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x200000, 0x03, 0x06, 0x03)) // 2 MiB ROM, 32 KiB RAM
+	if c.Mapper.(*MBC1).Multicart {
+		t.Fatalf("2 MiB cartridge detected as a multicart")
+	}
+	checkBanks(t, bus, "power on", bankCheck{0x0150, 0x00}, bankCheck{0x4000, 0x01})
+
+	bus.Write(0x2000, 0x00)
+	checkBanks(t, bus, "bank 0", bankCheck{0x4000, 0x01})
+	bus.Write(0x2000, 0x1f)
+	checkBanks(t, bus, "bank 1f", bankCheck{0x7fff, 0x1f})
+	bus.Write(0x2000, 0xe5) // upper bits are ignored
+	checkBanks(t, bus, "bank 05", bankCheck{0x4000, 0x05})
+
+	// BANK2 extends the ROM bank, and the zero check on BANK1 still maps 0x20 to 0x21
+	bus.Write(0x2000, 0x00)
+	bus.Write(0x4000, 0x01)
+	checkBanks(t, bus, "bank 21", bankCheck{0x4000, 0x21}, bankCheck{0x0150, 0x00})
+
+	// mode 1 applies BANK2 to the lower window too
+	bus.Write(0x6000, 0x01)
+	bus.Write(0x4000, 0x03)
+	checkBanks(t, bus, "mode 1", bankCheck{0x0150, 0x60}, bankCheck{0x4000, 0x61})
+
+	// RAM is disabled at power on and banked by BANK2 in mode 1
+	bus.Write(0xa000, 0x11)
+	if c.RAM[0] != 0x00 || bus.Read(0xa000) != 0xff {
+		t.Errorf("disabled RAM was written or read back %02x", bus.Read(0xa000))
+	}
+	bus.Write(0x0000, 0x0a)
+	bus.Write(0xa000, 0x33)
+	bus.Write(0x6000, 0x00)
+	bus.Write(0xa000, 0x44)
+	if c.RAM[3*0x2000] != 0x33 || c.RAM[0] != 0x44 {
+		t.Errorf("RAM banks 3 and 0 hold %02x and %02x, wanted 33 and 44", c.RAM[3*0x2000], c.RAM[0])
+	}
+	bus.Write(0x0000, 0x00)
+	if bus.Read(0xa000) != 0xff {
+		t.Errorf("RAM still readable after disabling it")
+	}
+
+	// 64 banks wrap on a 512 KiB ROM
+	_, small := insert(t, testROM(0x80000, 0x01, 0x04, 0x00))
+	small.Write(0x2000, 0x05)
+	small.Write(0x4000, 0x02)
+	checkBanks(t, small, "wrapped", bankCheck{0x4000, 0x05})
+}
+
+func TestMBC1Multicart(t *testing.T) {
+	t.Parallel()
+	rom := testROM(0x100000, 0x01, 0x05, 0x00)
+	for game := 0; game < 4; game++ {
+		copy(rom[game*0x40000+0x0104:], "NINTENDO LOGO BITMAP, 48 BYTES IN THE REAL THING")
+	}
+	fix_checksums(rom)
+	c, bus := insert(t, rom)
+	if !c.Mapper.(*MBC1).Multicart {
+		t.Fatalf("multicart not detected")
+	}
+
+	// BANK2 sits above 4 bits of BANK1, and bit 4 of BANK1 is not connected
+	bus.Write(0x4000, 0x01)
+	bus.Write(0x2000, 0x03)
+	checkBanks(t, bus, "game 1", bankCheck{0x4000, 0x13})
+	bus.Write(0x2000, 0x10)
+	checkBanks(t, bus, "bank 10", bankCheck{0x4000, 0x10})
+	bus.Write(0x6000, 0x01)
+	bus.Write(0x4000, 0x02)
+	checkBanks(t, bus, "game 2", bankCheck{0x0150, 0x20})
+}
+
+func TestMBC2(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x40000, 0x06, 0x03, 0x00)) // 256 KiB ROM
+	checkBanks(t, bus, "power on", bankCheck{0x4000, 0x01})
+
+	// address bit 8 selects the ROM bank register, anywhere in 0x0000-0x3FFF
+	bus.Write(0x2100, 0x0f)
+	checkBanks(t, bus, "bank 0f", bankCheck{0x4000, 0x0f})
+	bus.Write(0x0100, 0x00)
+	checkBanks(t, bus, "bank 0", bankCheck{0x4000, 0x01})
+	bus.Write(0x3eff, 0x0a) // bit 8 clear, enables RAM instead
+	checkBanks(t, bus, "after RAM enable", bankCheck{0x4000, 0x01})
+
+	// 512 half bytes mirrored over the whole window, the upper nibble reads as 1s
+	bus.Write(0xa001, 0x5c)
+	if c.RAM[1] != 0x0c || bus.Read(0xa001) != 0xfc || bus.Read(0xa201) != 0xfc || bus.Read(0xbe01) != 0xfc {
+		t.Errorf("RAM holds %02x and reads %02x %02x %02x, wanted 0c and fc", c.RAM[1], bus.Read(0xa001), bus.Read(0xa201), bus.Read(0xbe01))
+	}
+	bus.Write(0x0000, 0x00)
+	if bus.Read(0xa001) != 0xff {
+		t.Errorf("RAM still readable after disabling it")
+	}
+}
+
+func TestMBC3(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x200000, 0x13, 0x06, 0x03)) // 2 MiB ROM, 32 KiB RAM, no timer
+	if c.Mapper.(*MBC3).RTC != nil {
+		t.Fatalf("RTC present on a cartridge without a timer")
+	}
+	bus.Write(0x2000, 0x00)
+	checkBanks(t, bus, "bank 0", bankCheck{0x4000, 0x01})
+	bus.Write(0x2000, 0xff)
+	checkBanks(t, bus, "bank 7f", bankCheck{0x4000, 0x7f}, bankCheck{0x0150, 0x00})
+	bus.Write(0x2000, 0x20) // no MBC1 style zero check on the low bits
+	checkBanks(t, bus, "bank 20", bankCheck{0x4000, 0x20})
+
+	bus.Write(0x0000, 0x0a)
+	bus.Write(0x4000, 0x02)
+	bus.Write(0xa123, 0x42)
+	if c.RAM[2*0x2000+0x123] != 0x42 {
+		t.Errorf("RAM bank 2 was not written")
+	}
+	bus.Write(0x4000, 0x08) // RTC register without a timer
+	if bus.Read(0xa000) != 0xff {
+		t.Errorf("missing RTC reads %02x", bus.Read(0xa000))
+	}
+}
+
+func TestMBC3RTC(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x8000, 0x10, 0x00, 0x03))
+	clock := &ManualClock{T: time.Unix(1_700_000_000, 0)}
+	rtc := c.Mapper.(*MBC3).RTC
+	rtc.SetClock(clock)
+
+	latched := func() [RTC_REGISTERS]byte {
+		bus.Write(0x6000, 0x00)
+		bus.Write(0x6000, 0x01)
+		regs := [RTC_REGISTERS]byte{}
+		for i := range regs {
+			bus.Write(0x4000, byte(0x08+i))
+			regs[i] = bus.Read(0xa000)
+		}
+		return regs
+	}
+	bus.Write(0x0000, 0x0a)
+
+	clock.Advance(((256+1)*24*3600 + 2*3600 + 3*60 + 4) * time.Second)
+	if got, want := latched(), [...]byte{4, 3, 2, 1, 0x01}; got != want {
+		t.Errorf("after 257d 2h 3m 4s registers are % x, wanted % x", got, want)
+	}
+
+	// the latched copy only changes on a 0 then 1 write
+	clock.Advance(10 * time.Second)
+	bus.Write(0x4000, 0x08)
+	bus.Write(0x6000, 0x01)
+	if s := bus.Read(0xa000); s != 4 {
+		t.Errorf("seconds changed to %d without a latch", s)
+	}
+
+	// halted clocks don't count, and sub-second time doesn't leak
+	bus.Write(0x4000, 0x0c)
+	bus.Write(0xa000, RTC_HALT|0x01)
+	clock.Advance(time.Hour)
+	if got := latched(); got[RTC_S] != 14 || got[RTC_H] != 2 {
+		t.Errorf("halted clock reads % x", got)
+	}
+	bus.Write(0x4000, 0x0c)
+	bus.Write(0xa000, 0x01)
+	clock.Advance(1500 * time.Millisecond)
+	if got := latched(); got[RTC_S] != 15 {
+		t.Errorf("seconds is %d after 1.5 s, wanted 15", got[RTC_S])
+	}
+
+	// out of range values count up to the bit width without carrying, the day counter
+	// sets its carry bit when it overflows 511
+	bus.Write(0x4000, 0x08)
+	bus.Write(0xa000, 62)
+	bus.Write(0x4000, 0x0a)
+	bus.Write(0xa000, 23)
+	bus.Write(0x4000, 0x0b)
+	bus.Write(0xa000, 0xff)
+	clock.Advance(2 * time.Second)
+	if got, want := latched(), [...]byte{0, 3, 23, 0xff, 0x01}; got != want {
+		t.Errorf("after overflowing seconds registers are % x, wanted % x", got, want)
+	}
+	bus.Write(0x4000, 0x08)
+	bus.Write(0xa000, 59)
+	bus.Write(0x4000, 0x09)
+	bus.Write(0xa000, 59)
+	clock.Advance(time.Second)
+	if got, want := latched(), [...]byte{0, 0, 0, 0, RTC_CARRY}; got != want {
+		t.Errorf("after day 511 registers are % x, wanted % x", got, want)
+	}
+
+	// the 48 byte trailer restores the registers and counts the time since it was saved
+	trailer, _ := rtc.MarshalBinary()
+	if len(trailer) != RTC_TRAILER_SIZE {
+		t.Fatalf("trailer is %d bytes", len(trailer))
+	}
+	clock.Advance(90 * time.Second)
+	restored := NewRTC(clock)
+	if err := restored.UnmarshalBinary(trailer); err != nil {
+		t.Fatal(err)
+	}
+	restored.Latch()
+	if got, want := restored.Latched, [...]byte{30, 1, 0, 0, RTC_CARRY}; got != want {
+		t.Errorf("restored registers are % x, wanted % x", got, want)
+	}
+	if err := restored.UnmarshalBinary(trailer[:40]); err == nil {
+		t.Errorf("40 byte trailer was accepted")
+	}
+}
+
+func TestMBC5(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x800000, 0x1b, 0x08, 0x04)) // 8 MiB ROM, 128 KiB RAM
+	bus.Write(0x2000, 0x00)
+	checkBanks(t, bus, "bank 0", bankCheck{0x4000, 0x00})
+	bus.Write(0x2fff, 0x23)
+	bus.Write(0x3000, 0x01)
+	checkBanks(t, bus, "bank 123", bankCheck{0x4000, 0x23}, bankCheck{0x0150, 0x00})
+	if got := c.Mapper.(*MBC5).rom_bank; got != 0x123 {
+		t.Errorf("ROM bank is %03x, wanted 123", got)
+	}
+
+	bus.Write(0x0000, 0x1a) // only 0x0A enables RAM
+	bus.Write(0xa000, 0x11)
+	bus.Write(0x0000, 0x0a)
+	bus.Write(0x4000, 0x0f)
+	bus.Write(0xbfff, 0x22)
+	if c.RAM[0] != 0x00 || c.RAM[0x10*0x2000-1] != 0x22 {
+		t.Errorf("RAM holds %02x and %02x, wanted 00 and 22", c.RAM[0], c.RAM[0x10*0x2000-1])
+	}
+}
+
+func TestMBC5Rumble(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x8000, 0x1e, 0x00, 0x03))
+	clock := &ManualClock{T: time.Unix(1_700_000_000, 0)}
+	m := c.Mapper.(*MBC5)
+	m.Clock = clock
+	events := []RumbleEvent{}
+	m.OnRumble = func(e RumbleEvent) { events = append(events, e) }
+
+	bus.Write(0x0000, 0x0a)
+	bus.Write(0x4000, 0x0b) // motor on, RAM bank 3
+	bus.Write(0xa000, 0x33)
+	clock.Advance(250 * time.Millisecond)
+	bus.Write(0x4000, 0x0b) // no change, no event
+	bus.Write(0x4000, 0x03)
+	want := []RumbleEvent{{true, time.Unix(1_700_000_000, 0)}, {false, time.Unix(1_700_000_000, 250_000_000)}}
+	if len(events) != 2 || events[0].On != want[0].On || !events[0].Time.Equal(want[0].Time) || events[1].On != want[1].On || !events[1].Time.Equal(want[1].Time) {
+		t.Errorf("got rumble events %v, wanted %v", events, want)
+	}
+	if c.RAM[3*0x2000] != 0x33 || m.Rumble {
+		t.Errorf("motor bit leaked into the RAM bank or motor still on")
+	}
+}
+
+// mbc7EEPROM drives the 93LC56 pins at 0xA080 the way games bit bang them
+type mbc7EEPROM struct {
+	t   *testing.T
+	bus *hardware.Bus
+}
+
+func (e mbc7EEPROM) bit(di bool) bool {
+	b := byte(EEPROM_CS)
+	if di {
+		b |= EEPROM_DI
+	}
+	e.bus.Write(0xa080, b)
+	e.bus.Write(0xa080, b|EEPROM_CLK)
+	return e.bus.Read(0xa080)&EEPROM_DO != 0
+}
+
+func (e mbc7EEPROM) command(bits uint32, n int) {
+	// Select the chip, send the start bit and n command bits, MSB first
+	e.bus.Write(0xa080, 0x00)
+	e.bit(true)
+	for i := n - 1; i >= 0; i-- {
+		e.bit(bits>>i&1 != 0)
+	}
+}
+
+func (e mbc7EEPROM) write(addr byte, w uint16) {
+	e.command(0x1<<24|uint32(addr)<<16|uint32(w), 26)
+	e.bus.Write(0xa080, 0x00)
+}
+
+func (e mbc7EEPROM) read(addr byte) uint16 {
+	e.command(0x2<<8|uint32(addr), 10)
+	if e.bus.Read(0xa080)&EEPROM_DO != 0 {
+		e.t.Errorf("READ %02x: no dummy 0 bit", addr)
+	}
+	w := uint16(0)
+	for i := 0; i < 16; i++ {
+		w <<= 1
+		if e.bit(false) {
+			w |= 1
+		}
+	}
+	e.bus.Write(0xa080, 0x00)
+	return w
+}
+
+func TestMBC7(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x100000, 0x22, 0x05, 0x00))
+	bus.Write(0x2000, 0x3f)
+	checkBanks(t, bus, "bank 3f", bankCheck{0x4000, 0x3f}, bankCheck{0x0000, 0x00})
+
+	m := c.Mapper.(*MBC7)
+	m.Tilt(1, -0.5)
+	bus.Write(0xa000, 0x55) // ignored until both enables are written
+	bus.Write(0x0000, 0x0a)
+	if got := bus.Read(0xa020); got != 0xff {
+		t.Errorf("registers readable with only the first enable, got %02x", got)
+	}
+	bus.Write(0x4000, 0x40)
+
+	axes := func() (uint16, uint16) {
+		return uint16(bus.Read(0xa030))<<8 | uint16(bus.Read(0xa020)), uint16(bus.Read(0xa050))<<8 | uint16(bus.Read(0xa040))
+	}
+	bus.Write(0xa010, 0xaa) // not erased yet, no latch
+	if x, y := axes(); x != MBC7_ERASED || y != MBC7_ERASED {
+		t.Errorf("axes %04x %04x before the first latch, wanted 8000", x, y)
+	}
+	bus.Write(0xa000, 0x55)
+	bus.Write(0xa010, 0xaa)
+	if x, y := axes(); x != 0x81d0+0x70 || y != 0x81d0-0x38 {
+		t.Errorf("axes %04x %04x, wanted 8240 8198", x, y)
+	}
+	m.Tilt(0, 0)
+	if x, _ := axes(); x != 0x81d0+0x70 {
+		t.Errorf("axes changed without a latch")
+	}
+	bus.Write(0xa000, 0x55)
+	bus.Write(0xa010, 0xaa)
+	if x, y := axes(); x != MBC7_CENTER || y != MBC7_CENTER {
+		t.Errorf("axes %04x %04x at rest, wanted 81d0", x, y)
+	}
+}
+
+func TestMBC7EEPROM(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x100000, 0x22, 0x05, 0x00))
+	bus.Write(0x0000, 0x0a)
+	bus.Write(0x4000, 0x40)
+	e := mbc7EEPROM{t, bus}
+	if len(c.RAM) != MBC7_EEPROM_SIZE || e.read(0x05) != 0xffff {
+		t.Fatalf("EEPROM is %d bytes and word 5 is %04x, wanted 256 blank bytes", len(c.RAM), e.read(0x05))
+	}
+
+	e.write(0x05, 0x1234) // write protected after power on
+	if got := e.read(0x05); got != 0xffff {
+		t.Errorf("write while protected stored %04x", got)
+	}
+	e.command(0x0<<8|0xc0, 10) // EWEN
+	e.write(0x05, 0x1234)
+	e.write(0x7f, 0xbeef)
+	if got := e.read(0x05); got != 0x1234 {
+		t.Errorf("word 5 is %04x, wanted 1234", got)
+	}
+	if c.RAM[0x0a] != 0x34 || c.RAM[0x0b] != 0x12 || c.RAM[0xfe] != 0xef || c.RAM[0xff] != 0xbe {
+		t.Errorf("RAM holds % x, wanted words stored little endian", c.RAM[0x0a:0x0c])
+	}
+
+	e.command(0x3<<8|0x05, 10) // ERASE
+	e.bus.Write(0xa080, 0x00)
+	if got := e.read(0x05); got != 0xffff {
+		t.Errorf("erased word 5 is %04x", got)
+	}
+	e.command(0x0<<8|0x00, 10) // EWDS
+	e.command(0x0<<8|0x80, 10) // ERAL, ignored while protected
+	e.bus.Write(0xa080, 0x00)
+	if got := e.read(0x7f); got != 0xbeef {
+		t.Errorf("word 7f is %04x after a protected ERAL, wanted beef", got)
+	}
+
+	// Contents come back from a save like battery RAM
+	c2, bus2 := insert(t, c.ROM)
+	copy(c2.RAM, c.RAM)
+	bus2.Write(0x0000, 0x0a)
+	bus2.Write(0x4000, 0x40)
+	if got := (mbc7EEPROM{t, bus2}).read(0x7f); got != 0xbeef {
+		t.Errorf("restored word 7f is %04x, wanted beef", got)
+	}
+}
+
+func TestHuC1(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x100000, 0xff, 0x05, 0x03))
+	bus.Write(0x2000, 0x00)
+	checkBanks(t, bus, "bank 0 as 1", bankCheck{0x4000, 0x01})
+	bus.Write(0x2000, 0x3f)
+	checkBanks(t, bus, "bank 3f", bankCheck{0x4000, 0x3f}, bankCheck{0x0000, 0x00})
+
+	bus.Write(0x4000, 0x02)
+	bus.Write(0xa000, 0x22)
+	if c.RAM[2*0x2000] != 0x22 {
+		t.Errorf("RAM bank 2 holds %02x, wanted 22", c.RAM[2*0x2000])
+	}
+
+	m := c.Mapper.(*HuC1)
+	bus.Write(0x0000, 0x0e)
+	bus.Write(0xa000, 0x01)
+	if got := bus.Read(0xa000); got != 0xc1 {
+		t.Errorf("IR reads %02x with the LED looped back, wanted c1", got)
+	}
+	m.IR = &hardware.Loopback{}
+	if got := bus.Read(0xa000); got != 0xc0 {
+		t.Errorf("IR reads %02x in the dark, wanted c0", got)
+	}
+	bus.Write(0x0000, 0x0a)
+	if got := bus.Read(0xa000); got != 0x22 || c.RAM[2*0x2000] != 0x22 {
+		t.Errorf("RAM reads %02x after IR mode, wanted 22", got)
+	}
+
+	// on a link shared with the CGB port, the cartridge sees the port's LED and
+	// turning its own off leaves the port's lit
+	cpu := hardware.NewCPUModel(hardware.MODEL_CGB)
+	c.Insert(cpu.Bus)
+	link := &hardware.SharedLink{}
+	cpu.Infrared.Link = link.Connect()
+	if !c.SetInfrared(link.Connect()) {
+		t.Fatal("HuC1 has no infrared transceiver")
+	}
+	cpu.Bus.Write(0x0000, 0x0e)
+	cpu.Bus.Write(hardware.REG_RP, 0x01)
+	cpu.Bus.Write(0xa000, 0x00)
+	if got := cpu.Bus.Read(0xa000); got != 0xc1 {
+		t.Errorf("IR reads %02x with the CGB LED on, wanted c1", got)
+	}
+	cpu.Bus.Write(hardware.REG_RP, 0xc0)
+	if got := cpu.Bus.Read(hardware.REG_RP); got != 0xfe {
+		t.Errorf("RP reads %02x in the dark, wanted fe", got)
+	}
+}
+
+func TestHuC3(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x200000, 0xfe, 0x06, 0x03))
+	bus.Write(0x2000, 0x7f)
+	bus.Write(0x4000, 0x01)
+	checkBanks(t, bus, "bank 7f", bankCheck{0x4000, 0x7f})
+
+	bus.Write(0x0000, HUC3_RAM)
+	bus.Write(0xa000, 0x11)
+	bus.Write(0x0000, HUC3_RAM_READ)
+	bus.Write(0xa001, 0x22)
+	if got := bus.Read(0xa000); got != 0x11 || c.RAM[0x2001] != 0x00 {
+		t.Errorf("RAM reads %02x and the read only write stored %02x", got, c.RAM[0x2001])
+	}
+
+	m := c.Mapper.(*HuC3)
+	clock := &ManualClock{T: time.Unix(1_700_000_000, 0)}
+	m.RTC.SetClock(clock)
+	tones := []ToneEvent{}
+	m.OnTone = func(e ToneEvent) { tones = append(tones, e) }
+	run := func(cmd byte) byte {
+		bus.Write(0x0000, HUC3_COMMAND)
+		bus.Write(0xa000, cmd)
+		bus.Write(0x0000, HUC3_SEMAPHORE)
+		bus.Write(0xa000, 0xfe)
+		if bus.Read(0xa000)&0x01 == 0 {
+			t.Errorf("command %02x did not complete", cmd)
+		}
+		bus.Write(0x0000, HUC3_RESPONSE)
+		return bus.Read(0xa000)
+	}
+
+	// set 23:59 on day 0x123 through clock memory, then let two minutes pass
+	run(0x40)
+	run(0x50)
+	for _, n := range []byte{0x0f, 0x9, 0x5, 0x3, 0x2, 0x1} { // 1439 minutes, day 123
+		run(0x30 | n)
+	}
+	run(0x61)
+	clock.Advance(2*time.Minute + 30*time.Second)
+	run(0x60)
+	run(0x40)
+	got := []byte{}
+	for i := 0; i < 6; i++ {
+		got = append(got, run(0x10)&0x0f)
+	}
+	if want := []byte{0x1, 0x0, 0x0, 0x4, 0x2, 0x1}; string(got) != string(want) {
+		t.Errorf("clock memory holds %x, wanted %x", got, want)
+	}
+	if m.RTC.Minutes != 1 || m.RTC.Days != 0x124 {
+		t.Errorf("clock is at minute %d of day %x, wanted 1 of 124", m.RTC.Minutes, m.RTC.Days)
+	}
+
+	run(0x47)
+	run(0x52)
+	run(0x35) // tone 5 at 0x27
+	run(0x6e)
+	if len(tones) != 1 || tones[0].Tone != 5 || !tones[0].Time.Equal(clock.T) {
+		t.Errorf("got tones %v, wanted tone 5 at %v", tones, clock.T)
+	}
+
+	// the trailer counts time spent powered off
+	b, _ := m.RTC.MarshalBinary()
+	r := NewHuC3RTC(&ManualClock{T: clock.T.Add(24 * time.Hour)})
+	if err := r.UnmarshalBinary(b); err != nil || r.Minutes != 1 || r.Days != 0x125 {
+		t.Errorf("restored clock is at minute %d of day %x (%v), wanted 1 of 125", r.Minutes, r.Days, err)
+	}
+
+	bus.Write(0x0000, HUC3_IR)
+	bus.Write(0xa000, 0x01)
+	if got := bus.Read(0xa000); got != 0xc1 {
+		t.Errorf("IR reads %02x with the LED looped back, wanted c1", got)
+	}
+}
+
+func TestMMM01(t *testing.T) {
+	t.Parallel()
+	// 512 KiB compilation, the first game's MBC1 header at the start and the menu's at the end
+	rom := testROM(0x80000, 0x01, 0x02, 0x00)
+	menu := rom[len(rom)-MMM01_MENU_SIZE:]
+	copy(menu[ADDR_TITLE:], "MENU\x00\x00\x00\x00")
+	menu[ADDR_TYPE], menu[ADDR_ROM_SIZE], menu[ADDR_RAM_SIZE] = 0x0d, 0x04, 0x03
+	fix_checksums(menu)
+	c, bus := insert(t, rom)
+	if c.Header.Title != "MENU" || c.Header.Type.Mapper != MAPPER_MMM01 || len(c.RAM) != 0x8000 {
+		t.Fatalf("loaded %q %v with %d bytes of RAM, wanted the MMM01 menu header", c.Header.Title, c.Header.Type, len(c.RAM))
+	}
+	checkBanks(t, bus, "menu", bankCheck{0x0150, 0x1e}, bankCheck{0x4000, 0x1f})
+
+	// select the 128 KiB game at bank 0x10 with 8 KiB of RAM in bank 2, then lock
+	bus.Write(0x2000, 0x10)
+	bus.Write(0x6000, 0x30) // ROM bank bits 3 and 4 fixed
+	bus.Write(0x4000, 0x02)
+	checkBanks(t, bus, "menu before the lock", bankCheck{0x0150, 0x1e}, bankCheck{0x4000, 0x1f})
+	bus.Write(0x0000, 0x70) // RAM bank bits 0 and 1 fixed, lock
+	checkBanks(t, bus, "game", bankCheck{0x0150, 0x10}, bankCheck{0x4000, 0x11})
+
+	bus.Write(0x2000, 0x03)
+	checkBanks(t, bus, "game bank 3", bankCheck{0x4000, 0x13}, bankCheck{0x0000, 0x10})
+	bus.Write(0x2000, 0x1f)
+	checkBanks(t, bus, "game bank 7", bankCheck{0x4000, 0x17})
+	bus.Write(0x2000, 0x08)
+	checkBanks(t, bus, "game bank 0 as 1", bankCheck{0x4000, 0x11})
+
+	bus.Write(0x0000, 0x0a) // bit 6 clear does not unlock
+	bus.Write(0x4000, 0x33)
+	bus.Write(0x6000, 0x01)
+	bus.Write(0xa000, 0x42)
+	if c.RAM[2*0x2000] != 0x42 {
+		t.Errorf("game wrote outside its RAM bank")
+	}
+	checkBanks(t, bus, "game after bank writes", bankCheck{0x0150, 0x10}, bankCheck{0x4000, 0x11})
+}
+
+func TestCamera(t *testing.T) {
+	t.Parallel()
+	c, bus := insert(t, testROM(0x100000, 0xfc, 0x05, 0x04))
+	bus.Write(0x2000, 0x00)
+	checkBanks(t, bus, "bank 0", bankCheck{0x4000, 0x00})
+	bus.Write(0x2000, 0x3f)
+	checkBanks(t, bus, "bank 3f", bankCheck{0x4000, 0x3f})
+
+	bus.Write(0x4000, 0x03)
+	bus.Write(0xa000, 0x11) // writes need RAM enabled
+	bus.Write(0x0000, 0x0a)
+	bus.Write(0xa001, 0x22)
+	if c.RAM[3*0x2000] != 0x00 || bus.Read(0xa001) != 0x22 {
+		t.Errorf("RAM bank 3 holds % x, wanted 00 22", c.RAM[3*0x2000:3*0x2000+2])
+	}
+
+	// gray 100 on the left half and 150 on the right, black under 0x40, white from 0xc0
+	img := image.NewGray(image.Rect(0, 0, 256, 224))
+	for y := 0; y < 224; y++ {
+		for x := 0; x < 256; x++ {
+			img.SetGray(x, y, color.Gray{Y: 100 + 50*uint8(x/128)})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "scene.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	m := c.Mapper.(*Camera)
+	if err := m.LoadPNG(path); err != nil {
+		t.Fatal(err)
+	}
+
+	bus.Write(0x4000, 0x10)
+	bus.Write(0xa002, 0x10) // exposure 0x1000, gain 0
+	for i := 0; i < 16; i++ {
+		bus.Write(0xa006+uint16(i)*3, 0x40)
+		bus.Write(0xa087+uint16(i)*3, 0x80) // registers mirror every 0x80 bytes
+		bus.Write(0xa008+uint16(i)*3, 0xc0)
+	}
+	row := func(tile int) (byte, byte) {
+		// bit planes of the first row of a tile in the top row of the image
+		i := CAMERA_IMAGE_ADDR + tile*16
+		return c.RAM[i], c.RAM[i+1]
+	}
+	capture := func(what string, want ...byte) {
+		t.Helper()
+		bus.Write(0x4000, 0x10)
+		bus.Write(0xa000, 0x01)
+		elapsed := 0
+		for ; bus.Read(0xa000)&0x01 != 0 && elapsed < 1<<22; elapsed += 4 {
+			m.Tick(4)
+		}
+		// exposure 0x1000 with the N bit clear
+		if want := CAMERA_CAPTURE_T_STATES + CAMERA_N_T_STATES + 0x1000*CAMERA_EXPOSURE_T_STATES; elapsed != want {
+			t.Errorf("%s: capture took %d T-states, wanted %d", what, elapsed, want)
+		}
+		for i, tile := range []int{7, 8} {
+			lo, hi := row(tile)
+			if lo != want[i*2] || hi != want[i*2+1] {
+				t.Errorf("%s: tile %d row 0 is %02x %02x, wanted %02x %02x", what, tile, lo, hi, want[i*2], want[i*2+1])
+			}
+		}
+	}
+	capture("plain", 0x00, 0xff, 0xff, 0x00) // shade 2 left, shade 1 right
+
+	bus.Write(0xa001, 0x20) // horizontal edges at ratio 2 darken and lighten the boundary
+	bus.Write(0xa004, 0x40)
+	capture("edges", 0x01, 0xff, 0x7f, 0x00)
+
+	bus.Write(0xa001, 0x00)
+	bus.Write(0xa004, 0x08) // inverted, 155 and 105
+	capture("inverted", 0xff, 0x00, 0x00, 0xff)
+
+	bus.Write(0x4000, 0x00)
+	if bus.Read(0xa000+CAMERA_IMAGE_ADDR+8*16) != 0x00 || bus.Read(0xa000+CAMERA_IMAGE_ADDR+8*16+1) != 0xff {
+		t.Errorf("image not readable through RAM bank 0")
+	}
+}
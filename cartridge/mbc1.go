@@ -0,0 +1,95 @@
+package cartridge
+
+import (
+	"bytes"
+
+	"go-boy/hardware"
+)
+
+// MBC1 banks up to 2 MiB of ROM and 32 KiB of RAM, in mode 1 BANK2 also banks RAM and 0x0000-0x3FFF
+type MBC1 struct {
+	c          *Cartridge
+	ram_enable bool
+	bank1      uint8 // 0x2000-0x3FFF, 5 bits, 0 reads as 1
+	bank2      uint8 // 0x4000-0x5FFF, 2 bits
+	mode       uint8 // 0x6000-0x7FFF, 1 bit
+
+	// MBC1M multicarts wire only 4 bits of BANK1 to the ROM, BANK2 selects one of four 256 KiB games
+	Multicart bool
+}
+
+func new_mbc1(c *Cartridge) hardware.Device {
+	return &MBC1{c: c, bank1: 1, Multicart: is_mbc1_multicart(c.ROM)}
+}
+
+func is_mbc1_multicart(rom []byte) bool {
+	// Multicarts are 1 MiB with a game, and so another copy of the Nintendo logo, at bank 0x10
+	const logo, game = 0x0104, 0x10 * ROM_BANK_SIZE
+	if len(rom) != 0x100000 {
+		return false
+	}
+	return bytes.Equal(rom[logo:logo+0x30], rom[game+logo:game+logo+0x30])
+}
+
+func (m *MBC1) shift() uint8 {
+	// Position of BANK2 in the ROM bank number
+	if m.Multicart {
+		return 4
+	}
+	return 5
+}
+
+func (m *MBC1) rom_bank(addr uint16) int {
+	if addr < 0x4000 {
+		if m.mode == 0 {
+			return 0
+		}
+		return int(m.bank2) << m.shift()
+	}
+	low := m.bank1
+	if m.Multicart {
+		low &= 0x0f
+	}
+	return int(m.bank2)<<m.shift() | int(low)
+}
+
+func (m *MBC1) ram_bank() int {
+	if m.mode == 0 {
+		return 0
+	}
+	return int(m.bank2)
+}
+
+func (m *MBC1) Bank(addr uint16) int {
+	return m.c.rom_wrap(m.rom_bank(addr))
+}
+
+func (m *MBC1) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.rom_bank(addr), addr)
+	}
+	if i := m.c.ram_index(m.ram_bank(), addr); m.ram_enable && i >= 0 {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *MBC1) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ram_enable = b&0x0f == 0x0a
+	case addr < 0x4000:
+		m.bank1 = b & 0x1f
+		if m.bank1 == 0 { // the zero check only sees these 5 bits, so 0x20, 0x40 and 0x60 map one bank up
+			m.bank1 = 1
+		}
+	case addr < 0x6000:
+		m.bank2 = b & 0x03
+	case addr < 0x8000:
+		m.mode = b & 0x01
+	default:
+		if i := m.c.ram_index(m.ram_bank(), addr); m.ram_enable && i >= 0 {
+			m.c.RAM[i] = b
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package cartridge
+
+import "go-boy/hardware"
+
+const MBC2_RAM_SIZE = 512 // 4 bit cells, mirrored across 0xA000-0xBFFF
+
+// MBC2 banks 16 ROM banks and has 512x4 bits of RAM built in, address bit 8 selects its register
+type MBC2 struct {
+	c          *Cartridge
+	ram_enable bool
+	bank       uint8
+}
+
+func new_mbc2(c *Cartridge) hardware.Device {
+	c.RAM = make([]byte, MBC2_RAM_SIZE)
+	return &MBC2{c: c, bank: 1}
+}
+
+func (m *MBC2) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.bank))
+}
+
+func (m *MBC2) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	if !m.ram_enable {
+		return 0xff
+	}
+	return m.c.RAM[addr%MBC2_RAM_SIZE] | 0xf0 // only the low nibble is stored
+}
+
+func (m *MBC2) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x4000 && addr&0x0100 == 0:
+		m.ram_enable = b&0x0f == 0x0a
+	case addr < 0x4000:
+		m.bank = b & 0x0f
+		if m.bank == 0 {
+			m.bank = 1
+		}
+	case addr >= 0xA000 && m.ram_enable:
+		m.c.RAM[addr%MBC2_RAM_SIZE] = b & 0x0f
+	}
+}
@@ -0,0 +1,83 @@
+package cartridge
+
+import "go-boy/hardware"
+
+// MBC3 banks ROM and RAM and maps the RTC at RAM banks 0x08-0x0C, MBC30 has 8 bit ROM banks and 8 RAM banks
+type MBC3 struct {
+	c          *Cartridge
+	ram_enable bool // also enables the RTC registers
+	rom_bank   uint8
+	ram_bank   uint8 // 0x00-0x07 RAM bank, 0x08-0x0C RTC register
+	latch      uint8 // last value written to 0x6000-0x7FFF
+
+	RTC *RTC // nil unless the cartridge type has a timer
+}
+
+func new_mbc3(c *Cartridge) hardware.Device {
+	m := &MBC3{c: c, rom_bank: 1}
+	if c.Header.Type.Timer {
+		m.RTC = NewRTC(SystemClock{})
+	}
+	return m
+}
+
+func (m *MBC3) rom_mask() uint8 {
+	if len(m.c.ROM) > 0x200000 {
+		return 0xff // MBC30
+	}
+	return 0x7f
+}
+
+func (m *MBC3) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.rom_bank))
+}
+
+func (m *MBC3) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	if !m.ram_enable {
+		return 0xff
+	}
+	if m.ram_bank >= 0x08 {
+		if m.RTC == nil || m.ram_bank > 0x0c {
+			return 0xff
+		}
+		return m.RTC.Read(int(m.ram_bank - 0x08))
+	}
+	if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *MBC3) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ram_enable = b&0x0f == 0x0a
+	case addr < 0x4000:
+		m.rom_bank = b & m.rom_mask()
+		if m.rom_bank == 0 {
+			m.rom_bank = 1
+		}
+	case addr < 0x6000:
+		m.ram_bank = b & 0x0f
+	case addr < 0x8000:
+		if m.latch == 0x00 && b == 0x01 && m.RTC != nil {
+			m.RTC.Latch()
+		}
+		m.latch = b
+	case !m.ram_enable:
+	case m.ram_bank >= 0x08:
+		if m.RTC != nil && m.ram_bank <= 0x0c {
+			m.RTC.Write(int(m.ram_bank-0x08), b)
+		}
+	default:
+		if i := m.c.ram_index(int(m.ram_bank), addr); i >= 0 {
+			m.c.RAM[i] = b
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cartridge
+
+import (
+	"time"
+
+	"go-boy/hardware"
+)
+
+type RumbleEvent struct {
+	On   bool      // motor state after the change
+	Time time.Time // when the game changed it, from the cartridge's Clock
+}
+
+// MBC5 banks 512 ROM banks, bank 0 included, and 16 RAM banks, rumble cartridges use RAM bank bit 3 for the motor
+type MBC5 struct {
+	c          *Cartridge
+	ram_enable bool
+	rom_bank   uint16
+	ram_bank   uint8
+
+	Rumble   bool // motor state
+	OnRumble func(RumbleEvent)
+	Clock    Clock // timestamps rumble events, SystemClock unless replaced
+}
+
+func new_mbc5(c *Cartridge) hardware.Device {
+	return &MBC5{c: c, rom_bank: 1, Clock: SystemClock{}}
+}
+
+func (m *MBC5) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.rom_bank))
+}
+
+func (m *MBC5) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	if i := m.c.ram_index(int(m.ram_bank), addr); m.ram_enable && i >= 0 {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *MBC5) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ram_enable = b == 0x0a // all 8 bits are decoded, unlike MBC1
+	case addr < 0x3000:
+		m.rom_bank = m.rom_bank&0x100 | uint16(b)
+	case addr < 0x4000:
+		m.rom_bank = m.rom_bank&0xff | uint16(b&0x01)<<8
+	case addr < 0x6000:
+		m.ram_bank = b & 0x0f
+		if m.c.Header.Type.Rumble {
+			m.ram_bank &= 0x07
+			m.set_rumble(b&0x08 != 0)
+		}
+	case addr < 0x8000:
+	default:
+		if i := m.c.ram_index(int(m.ram_bank), addr); m.ram_enable && i >= 0 {
+			m.c.RAM[i] = b
+		}
+	}
+}
+
+func (m *MBC5) set_rumble(on bool) {
+	if on == m.Rumble {
+		return
+	}
+	m.Rumble = on
+	if m.OnRumble != nil {
+		m.OnRumble(RumbleEvent{On: on, Time: m.Clock.Now()})
+	}
+}
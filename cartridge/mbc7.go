@@ -0,0 +1,246 @@
+package cartridge
+
+import "go-boy/hardware"
+
+const MBC7_EEPROM_SIZE = 256 // 93LC56, 128 words of 16 bits
+
+// Accelerometer reading at rest and the change for 1 g of tilt
+const (
+	MBC7_CENTER  = 0x81D0
+	MBC7_GRAVITY = 0x70
+	MBC7_ERASED  = 0x8000 // reading after 0x55 is written, before the next latch
+)
+
+// MBC7 banks ROM like MBC5, its RAM window holds the accelerometer and EEPROM once 0x0A and 0x40 enable it
+type MBC7 struct {
+	c           *Cartridge
+	ram_enable1 bool
+	ram_enable2 bool
+	rom_bank    uint8
+
+	tilt_x, tilt_y uint16 // current accelerometer output
+	x, y           uint16 // latched values the game reads
+	erased         bool   // 0x55 written, the next 0xAA latches
+	eeprom         eeprom
+}
+
+func new_mbc7(c *Cartridge) hardware.Device {
+	c.RAM = make([]byte, MBC7_EEPROM_SIZE)
+	for i := range c.RAM {
+		c.RAM[i] = 0xff // blank EEPROM
+	}
+	m := &MBC7{c: c, rom_bank: 1, x: MBC7_ERASED, y: MBC7_ERASED}
+	m.eeprom.data = c.RAM
+	m.eeprom.do = true
+	m.Tilt(0, 0)
+	return m
+}
+
+func (m *MBC7) Tilt(x, y float64) {
+	// Set the accelerometer input in g, x is positive tilted right and y tilted away from the player
+	m.tilt_x = uint16(int(MBC7_CENTER) + int(x*MBC7_GRAVITY))
+	m.tilt_y = uint16(int(MBC7_CENTER) + int(y*MBC7_GRAVITY))
+}
+
+func (m *MBC7) Bank(addr uint16) int {
+	if addr < 0x4000 {
+		return 0
+	}
+	return m.c.rom_wrap(int(m.rom_bank))
+}
+
+func (m *MBC7) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.Bank(addr), addr)
+	}
+	if !m.ram_enable1 || !m.ram_enable2 || addr >= 0xB000 {
+		return 0xff
+	}
+	switch (addr >> 4) & 0x0f {
+	case 0x2:
+		return byte(m.x)
+	case 0x3:
+		return byte(m.x >> 8)
+	case 0x4:
+		return byte(m.y)
+	case 0x5:
+		return byte(m.y >> 8)
+	case 0x6:
+		return 0x00 // no Z axis
+	case 0x8:
+		return m.eeprom.read()
+	}
+	return 0xff
+}
+
+func (m *MBC7) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ram_enable1 = b == 0x0a
+		return
+	case addr < 0x4000:
+		m.rom_bank = b
+		return
+	case addr < 0x6000:
+		m.ram_enable2 = b == 0x40
+		return
+	case addr < 0xA000:
+		return
+	}
+	if !m.ram_enable1 || !m.ram_enable2 || addr >= 0xB000 {
+		return
+	}
+	switch (addr >> 4) & 0x0f {
+	case 0x0:
+		if b == 0x55 {
+			m.x, m.y = MBC7_ERASED, MBC7_ERASED
+			m.erased = true
+		}
+	case 0x1:
+		if b == 0xaa && m.erased {
+			m.x, m.y = m.tilt_x, m.tilt_y
+			m.erased = false
+		}
+	case 0x8:
+		m.eeprom.write(b)
+	}
+}
+
+type eeprom_state uint8
+
+const (
+	EEPROM_IDLE     eeprom_state = iota // waiting for a start bit
+	EEPROM_COMMAND                      // shifting in 2 opcode and 8 address bits
+	EEPROM_DATA_IN                      // shifting in 16 bits to write
+	EEPROM_DATA_OUT                     // shifting out 16 bits read
+	EEPROM_DONE                         // waiting for CS to drop
+)
+
+// Bits of the EEPROM register at 0xA080
+const (
+	EEPROM_CS  = 0x80
+	EEPROM_CLK = 0x40
+	EEPROM_DI  = 0x02
+	EEPROM_DO  = 0x01
+)
+
+// 93LC56 in 16 bit mode, commands are a start bit and 10 bits clocked in while CS is high
+type eeprom struct {
+	data         []byte
+	pins         byte // CS, CLK and DI as last written
+	do           bool
+	write_enable bool
+
+	state eeprom_state
+	shift uint16
+	bits  int
+	addr  int  // word address of the command
+	all   bool // WRAL writes every word
+}
+
+func (e *eeprom) read() byte {
+	b := e.pins & (EEPROM_CS | EEPROM_CLK | EEPROM_DI)
+	if e.do {
+		b |= EEPROM_DO
+	}
+	return b
+}
+
+func (e *eeprom) word(addr int) uint16 {
+	return uint16(e.data[addr*2]) | uint16(e.data[addr*2+1])<<8
+}
+
+func (e *eeprom) set_word(addr int, w uint16) {
+	e.data[addr*2], e.data[addr*2+1] = byte(w), byte(w>>8)
+}
+
+func (e *eeprom) write(b byte) {
+	rising := e.pins&EEPROM_CLK == 0 && b&EEPROM_CLK != 0
+	e.pins = b
+	if b&EEPROM_CS == 0 {
+		e.state = EEPROM_IDLE
+		e.do = true
+		return
+	}
+	if rising {
+		e.clock(b&EEPROM_DI != 0)
+	}
+}
+
+func (e *eeprom) clock(di bool) {
+	bit := uint16(0)
+	if di {
+		bit = 1
+	}
+	switch e.state {
+	case EEPROM_IDLE:
+		if di {
+			e.state = EEPROM_COMMAND
+			e.shift, e.bits = 0, 0
+		}
+	case EEPROM_COMMAND:
+		e.shift = e.shift<<1 | bit
+		if e.bits++; e.bits == 10 {
+			e.command()
+		}
+	case EEPROM_DATA_IN:
+		e.shift = e.shift<<1 | bit
+		if e.bits++; e.bits < 16 {
+			return
+		}
+		if e.write_enable {
+			for a := 0; a < MBC7_EEPROM_SIZE/2; a++ {
+				if e.all || a == e.addr {
+					e.set_word(a, e.shift)
+				}
+			}
+		}
+		e.do = true // ready
+		e.state = EEPROM_DONE
+	case EEPROM_DATA_OUT:
+		e.do = e.shift&0x8000 != 0
+		e.shift <<= 1
+		if e.bits++; e.bits == 16 {
+			e.state = EEPROM_DONE
+		}
+	}
+}
+
+func (e *eeprom) command() {
+	op := e.shift >> 8
+	e.addr = int(e.shift & 0x7f) // the top address bit is a don't care
+	sub := e.shift >> 6 & 0x03   // selects the command when op is 0
+	e.all = false
+	e.shift, e.bits = 0, 0
+	e.state = EEPROM_DONE
+	switch op {
+	case 0x2: // READ, a dummy 0 comes out first
+		e.shift = e.word(e.addr)
+		e.do = false
+		e.state = EEPROM_DATA_OUT
+	case 0x1: // WRITE
+		e.state = EEPROM_DATA_IN
+	case 0x3: // ERASE
+		if e.write_enable {
+			e.set_word(e.addr, 0xffff)
+		}
+		e.do = true
+	case 0x0:
+		switch sub {
+		case 0x0: // EWDS
+			e.write_enable = false
+		case 0x1: // WRAL
+			e.all = true
+			e.state = EEPROM_DATA_IN
+		case 0x2: // ERAL
+			if e.write_enable {
+				for a := 0; a < MBC7_EEPROM_SIZE/2; a++ {
+					e.set_word(a, 0xffff)
+				}
+			}
+			e.do = true
+		case 0x3: // EWEN
+			e.write_enable = true
+		}
+	}
+}
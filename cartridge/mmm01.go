@@ -0,0 +1,99 @@
+package cartridge
+
+import "go-boy/hardware"
+
+// MMM01 multicart mapper, the menu in the last 32 KiB sets a game's banks and masks and bit 6 of 0x0000 locks them
+type MMM01 struct {
+	c          *Cartridge
+	mapped     bool // locked into the selected game
+	ram_enable bool
+	rom_bank   uint16 // 9 bits, 0x2000 bits 0-6 then 0x4000 bits 4-5
+	ram_bank   uint8  // 4 bits, 0x4000 bits 0-3
+	rom_fixed  uint8  // bits of the low 5 ROM bank bits the game cannot change
+	ram_fixed  uint8  // bits of the low 2 RAM bank bits the game cannot change
+	mode       uint8
+}
+
+func new_mmm01(c *Cartridge) hardware.Device {
+	return &MMM01{c: c}
+}
+
+func (m *MMM01) rom_writable() uint16 {
+	return 0x1f &^ uint16(m.rom_fixed)
+}
+
+func (m *MMM01) ram_writable() uint8 {
+	return 0x03 &^ m.ram_fixed
+}
+
+func (m *MMM01) rom(addr uint16) int {
+	if !m.mapped {
+		// The upper address lines are held high, ROM bank numbers wrap so this is the last 32 KiB
+		return 0x1fe | int(addr>>14)
+	}
+	w := m.rom_writable()
+	if addr < 0x4000 {
+		return int(m.rom_bank &^ w)
+	}
+	low := m.rom_bank & w
+	if low == 0 { // the zero check only sees the bits the game can change
+		low = 1
+	}
+	return int(m.rom_bank&^w | low)
+}
+
+func (m *MMM01) ram() int {
+	if m.mode == 0 {
+		return int(m.ram_bank &^ m.ram_writable())
+	}
+	return int(m.ram_bank)
+}
+
+func (m *MMM01) Bank(addr uint16) int {
+	return m.c.rom_wrap(m.rom(addr))
+}
+
+func (m *MMM01) Read(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.c.rom_byte(m.rom(addr), addr)
+	}
+	if i := m.c.ram_index(m.ram(), addr); m.ram_enable && i >= 0 {
+		return m.c.RAM[i]
+	}
+	return 0xff
+}
+
+func (m *MMM01) Write(addr uint16, b byte) {
+	switch {
+	case addr < 0x2000:
+		m.ram_enable = b&0x0f == 0x0a
+		if !m.mapped {
+			m.ram_fixed = b >> 4 & 0x03
+			m.mapped = b&0x40 != 0
+		}
+	case addr < 0x4000:
+		if !m.mapped {
+			m.rom_bank = m.rom_bank&0x180 | uint16(b&0x7f)
+			return
+		}
+		w := m.rom_writable()
+		m.rom_bank = m.rom_bank&^w | uint16(b)&w
+	case addr < 0x6000:
+		if !m.mapped {
+			m.ram_bank = b & 0x0f
+			m.rom_bank = m.rom_bank&0x7f | uint16(b>>4&0x03)<<7
+			return
+		}
+		w := m.ram_writable()
+		m.ram_bank = m.ram_bank&^w | b&w
+	case addr < 0x8000:
+		m.mode = b & 0x01
+		if !m.mapped {
+			m.rom_fixed = (b >> 2 & 0x0f) << 1 // the mask covers bits 1-4
+		}
+	default:
+		if i := m.c.ram_index(m.ram(), addr); m.ram_enable && i >= 0 {
+			m.c.RAM[i] = b
+		}
+	}
+}
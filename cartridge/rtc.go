@@ -0,0 +1,189 @@
+package cartridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Clock supplies the time a cartridge clock counts from, SystemClock unless SetClock swaps in a ManualClock
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock only moves when told to, for deterministic tests
+type ManualClock struct {
+	T time.Time
+}
+
+func (c *ManualClock) Now() time.Time {
+	return c.T
+}
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.T = c.T.Add(d)
+}
+
+const (
+	RTC_S  = iota // seconds, 6 bits
+	RTC_M         // minutes, 6 bits
+	RTC_H         // hours, 5 bits
+	RTC_DL        // day counter bits 0-7
+	RTC_DH        // bit 0 day counter bit 8, bit 6 halt, bit 7 day counter carry
+	RTC_REGISTERS
+)
+
+var rtc_masks = [RTC_REGISTERS]byte{0x3f, 0x3f, 0x1f, 0xff, 0xc1}
+
+const (
+	RTC_HALT  = 0x40
+	RTC_CARRY = 0x80
+)
+
+// Size of the RTC trailer BGB and VBA-M append to save RAM, the live and latched registers then a UNIX time
+const (
+	RTC_TRAILER_SIZE        = 48
+	RTC_TRAILER_SIZE_LEGACY = 44 // same with a 32 bit timestamp
+)
+
+// RTC is the MBC3 real time clock, brought up to date from its Clock whenever it is accessed
+type RTC struct {
+	Registers [RTC_REGISTERS]byte
+	Latched   [RTC_REGISTERS]byte
+
+	clock Clock
+	last  time.Time // host time the registers were last brought up to date
+}
+
+func NewRTC(clock Clock) *RTC {
+	return &RTC{clock: clock, last: clock.Now()}
+}
+
+func (r *RTC) SetClock(clock Clock) {
+	// Switch clock source, time elapsed on the old clock is kept
+	r.update()
+	r.clock = clock
+	r.last = clock.Now()
+}
+
+func (r *RTC) halted() bool {
+	return r.Registers[RTC_DH]&RTC_HALT != 0
+}
+
+func (r *RTC) tick() {
+	// Count one second the way the counters do, registers set out of range overflow at
+	// their bit width without carrying into the next register
+	reg := &r.Registers
+	if reg[RTC_S] = (reg[RTC_S] + 1) & 0x3f; reg[RTC_S] != 60 {
+		return
+	}
+	reg[RTC_S] = 0
+	if reg[RTC_M] = (reg[RTC_M] + 1) & 0x3f; reg[RTC_M] != 60 {
+		return
+	}
+	reg[RTC_M] = 0
+	if reg[RTC_H] = (reg[RTC_H] + 1) & 0x1f; reg[RTC_H] != 24 {
+		return
+	}
+	reg[RTC_H] = 0
+	r.set_days(r.days() + 1)
+}
+
+func (r *RTC) days() int {
+	return int(r.Registers[RTC_DH]&0x01)<<8 | int(r.Registers[RTC_DL])
+}
+
+func (r *RTC) set_days(days int) {
+	if days > 0x1ff {
+		r.Registers[RTC_DH] |= RTC_CARRY
+	}
+	days &= 0x1ff
+	r.Registers[RTC_DL] = byte(days)
+	r.Registers[RTC_DH] = r.Registers[RTC_DH]&^0x01 | byte(days>>8)
+}
+
+func (r *RTC) Advance(seconds int64) {
+	// Count a number of seconds, unless the clock is halted
+	if r.halted() {
+		return
+	}
+	reg := &r.Registers
+	for seconds > 0 && (reg[RTC_S] >= 60 || reg[RTC_M] >= 60 || reg[RTC_H] >= 24) {
+		r.tick()
+		seconds--
+	}
+	total := int64(reg[RTC_S]) + int64(reg[RTC_M])*60 + int64(reg[RTC_H])*3600 + seconds
+	days := int64(r.days()) + total/86400
+	total %= 86400
+	reg[RTC_S] = byte(total % 60)
+	reg[RTC_M] = byte(total / 60 % 60)
+	reg[RTC_H] = byte(total / 3600)
+	r.set_days(int(days))
+}
+
+func (r *RTC) update() {
+	// Count the whole seconds elapsed on the clock since the last update
+	now := r.clock.Now()
+	elapsed := int64(now.Sub(r.last) / time.Second)
+	if elapsed <= 0 {
+		return
+	}
+	r.last = r.last.Add(time.Duration(elapsed) * time.Second)
+	r.Advance(elapsed)
+}
+
+func (r *RTC) Latch() {
+	// Copy the live registers to the ones the game reads
+	r.update()
+	r.Latched = r.Registers
+}
+
+func (r *RTC) Read(reg int) byte {
+	return r.Latched[reg]
+}
+
+func (r *RTC) Write(reg int, b byte) {
+	r.update()
+	if reg == RTC_S {
+		r.last = r.clock.Now() // writing seconds restarts the sub-second divider
+	}
+	r.Registers[reg] = b & rtc_masks[reg]
+}
+
+func (r *RTC) MarshalBinary() ([]byte, error) {
+	// Encode the 48 byte save RAM trailer
+	r.update()
+	b := make([]byte, RTC_TRAILER_SIZE)
+	for i := 0; i < RTC_REGISTERS; i++ {
+		binary.LittleEndian.PutUint32(b[i*4:], uint32(r.Registers[i]))
+		binary.LittleEndian.PutUint32(b[20+i*4:], uint32(r.Latched[i]))
+	}
+	binary.LittleEndian.PutUint64(b[40:], uint64(r.last.Unix()))
+	return b, nil
+}
+
+func (r *RTC) UnmarshalBinary(b []byte) error {
+	// Decode a 48 or 44 byte save RAM trailer and count the time elapsed since it was written
+	if len(b) != RTC_TRAILER_SIZE && len(b) != RTC_TRAILER_SIZE_LEGACY {
+		return fmt.Errorf("RTC trailer is %d bytes, expected %d or %d", len(b), RTC_TRAILER_SIZE, RTC_TRAILER_SIZE_LEGACY)
+	}
+	for i := 0; i < RTC_REGISTERS; i++ {
+		r.Registers[i] = byte(binary.LittleEndian.Uint32(b[i*4:])) & rtc_masks[i]
+		r.Latched[i] = byte(binary.LittleEndian.Uint32(b[20+i*4:])) & rtc_masks[i]
+	}
+	var saved int64
+	if len(b) == RTC_TRAILER_SIZE {
+		saved = int64(binary.LittleEndian.Uint64(b[40:]))
+	} else {
+		saved = int64(binary.LittleEndian.Uint32(b[40:]))
+	}
+	r.last = time.Unix(saved, 0)
+	r.update()
+	return nil
+}
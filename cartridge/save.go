@@ -0,0 +1,149 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// T-states between checks for RAM changes and how long RAM stays unchanged before a SaveFile writes it
+const (
+	SAVE_CHECK_T_STATES  = 70224
+	SAVE_SETTLE_T_STATES = 4 * 1024 * 1024
+)
+
+// A clock saved after RAM, as the MBC3 and HuC3 trailers other emulators append
+type save_trailer interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+func (c *Cartridge) trailer() save_trailer {
+	switch m := c.Mapper.(type) {
+	case *MBC3:
+		if m.RTC != nil {
+			return m.RTC
+		}
+	case *HuC3:
+		return m.RTC
+	}
+	return nil
+}
+
+func (c *Cartridge) SaveData() ([]byte, error) {
+	// Encode the battery backed state the way other emulators lay out .sav files: a raw
+	// dump of RAM, MBC2 and MBC7 storage included, followed by any clock trailer
+	data := append([]byte(nil), c.RAM...)
+	if t := c.trailer(); t != nil {
+		b, err := t.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func (c *Cartridge) LoadSaveData(data []byte) error {
+	// Restore state encoded by SaveData, a save without the clock trailer leaves the clock alone
+	if len(data) < len(c.RAM) {
+		return fmt.Errorf("save data is %d bytes, the cartridge has %d bytes of RAM", len(data), len(c.RAM))
+	}
+	rest := data[len(c.RAM):]
+	t := c.trailer()
+	if len(rest) > 0 && t == nil {
+		return fmt.Errorf("save data is %d bytes, the cartridge has %d bytes of RAM and no clock", len(data), len(c.RAM))
+	}
+	if len(rest) > 0 {
+		if err := t.UnmarshalBinary(rest); err != nil {
+			return err
+		}
+	}
+	copy(c.RAM, data)
+	return nil
+}
+
+func SavePath(rom_path string) string {
+	// The .sav file next to a ROM, game.gb saves to game.sav
+	return strings.TrimSuffix(rom_path, filepath.Ext(rom_path)) + ".sav"
+}
+
+// SaveFile writes a battery backed cartridge's .sav file when RAM settles while attached, and on Flush
+type SaveFile struct {
+	Path string
+	c    *Cartridge
+
+	written []byte // RAM as last read from or written to the file
+	last    []byte // RAM at the previous check
+	elapsed uint32 // T-states since the previous check
+	settled uint32 // T-states RAM has been unchanged
+
+	OnError func(error) // host callback invoked when a write made while running fails
+}
+
+func OpenSave(c *Cartridge, path string) (*SaveFile, error) {
+	// Load the cartridge's battery backed state from path, a missing file is a new save
+	if !c.Header.Type.Battery {
+		return nil, fmt.Errorf("%v cartridges have no battery", c.Header.Type)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := c.LoadSaveData(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	s := &SaveFile{Path: path, c: c}
+	s.written = append([]byte(nil), c.RAM...)
+	s.last = append([]byte(nil), c.RAM...)
+	return s, nil
+}
+
+func (s *SaveFile) Flush() error {
+	// Write the save file, through a temporary file so a failed write keeps the old one
+	data, err := s.c.SaveData()
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return err
+	}
+	copy(s.written, s.c.RAM)
+	return nil
+}
+
+func (s *SaveFile) Tick(t_states uint8) {
+	// Flush once RAM has differed from the file and been left alone for SAVE_SETTLE_T_STATES
+	if s.elapsed += uint32(t_states); s.elapsed < SAVE_CHECK_T_STATES {
+		return
+	}
+	s.elapsed = 0
+	if !bytes.Equal(s.c.RAM, s.last) {
+		copy(s.last, s.c.RAM)
+		s.settled = 0
+		return
+	}
+	if s.settled < SAVE_SETTLE_T_STATES {
+		s.settled += SAVE_CHECK_T_STATES
+	}
+	if s.settled < SAVE_SETTLE_T_STATES || bytes.Equal(s.c.RAM, s.written) {
+		return
+	}
+	if err := s.Flush(); err != nil {
+		s.settled = 0 // retry after another wait
+		if s.OnError != nil {
+			s.OnError(err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"go-boy/cartridge"
+	"os"
+)
+
+// Prints the cartridge header of each ROM given on the command line and checks it
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: rominfo file.gb...")
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, path := range os.Args[1:] {
+		if err := rominfo(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+func rominfo(path string) error {
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	h, err := cartridge.ParseHeader(rom)
+	if err != nil {
+		return err
+	}
+
+	licensee := fmt.Sprintf("%02X", h.OldLicensee)
+	if h.OldLicensee == 0x33 {
+		licensee = fmt.Sprintf("%q (new)", h.NewLicensee)
+	}
+	fmt.Printf("%s\n", path)
+	fmt.Printf("  Title:           %q\n", h.Title)
+	if h.Manufacturer != "" {
+		fmt.Printf("  Manufacturer:    %s\n", h.Manufacturer)
+	}
+	fmt.Printf("  CGB flag:        %02X\n", h.CGBFlag)
+	fmt.Printf("  SGB flag:        %02X\n", h.SGBFlag)
+	fmt.Printf("  Type:            %02X %v\n", h.TypeCode, h.Type)
+	fmt.Printf("  ROM size:        %02X %d KiB (file is %d KiB)\n", h.ROMSizeCode, h.ROMSize()/1024, len(rom)/1024)
+	fmt.Printf("  RAM size:        %02X %d KiB\n", h.RAMSizeCode, h.RAMSize()/1024)
+	fmt.Printf("  Destination:     %s\n", h.DestinationName())
+	fmt.Printf("  Licensee:        %s\n", licensee)
+	fmt.Printf("  Version:         %d\n", h.Version)
+	fmt.Printf("  Header checksum: %02X\n", h.HeaderChecksum)
+	fmt.Printf("  Global checksum: %04X\n", h.GlobalChecksum)
+
+	// A bad global checksum or an overdump is reported but still loads, like on hardware
+	_, err = cartridge.Load(rom)
+	if verify_err := h.Verify(rom); err == nil && verify_err != nil {
+		fmt.Printf("  Warning:         %v\n", verify_err)
+	}
+	if err == nil && len(rom) > h.ROMSize() {
+		fmt.Printf("  Warning:         file is %d bytes, only the %d the header declares are loaded\n", len(rom), h.ROMSize())
+	}
+	return err
+}
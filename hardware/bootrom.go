@@ -0,0 +1,83 @@
+package hardware
+
+import "fmt"
+
+const REG_BANK = 0xFF50 // Boot ROM disable, any write unmaps the boot ROM
+
+const (
+	BOOT_ROM_SIZE_DMG = 0x0100 // 0x0000-0x00FF
+	BOOT_ROM_SIZE_CGB = 0x0900 // 0x0000-0x00FF and 0x0200-0x08FF, the header shows through in between
+)
+
+type BootROM struct {
+	bus       *Bus
+	image     []byte
+	cartridge Device // ROM0 device underneath the overlay
+	Mapped    bool
+}
+
+func LoadBootROM(cpu *CPU, image []byte) (*BootROM, error) {
+	// Overlay a DMG or CGB boot ROM on bank 0 and restart the CPU at 0x0000, the cartridge
+	// may be inserted before or after
+	if len(image) != BOOT_ROM_SIZE_DMG && len(image) != BOOT_ROM_SIZE_CGB {
+		return nil, fmt.Errorf("boot ROM is %d bytes, expected %d for DMG or %d for CGB", len(image), BOOT_ROM_SIZE_DMG, BOOT_ROM_SIZE_CGB)
+	}
+	if len(image) == BOOT_ROM_SIZE_CGB && !cpu.Model.CGB() {
+		return nil, fmt.Errorf("CGB boot ROM cannot run on a %v", cpu.Model)
+	}
+
+	b := &BootROM{bus: cpu.Bus, image: image, cartridge: cpu.Bus.Device(REGION_ROM0), Mapped: true}
+	cpu.Bus.Map(REGION_ROM0, b)
+	cpu.Bus.MapIO(REG_BANK, b)
+
+	// The boot ROM starts from power on, not from the post boot state
+	cpu.A, cpu.F, cpu.B, cpu.C, cpu.D, cpu.E, cpu.H, cpu.L = 0, 0, 0, 0, 0, 0, 0, 0
+	cpu.SP = 0x0000
+	cpu.PC = 0x0000
+	return b, nil
+}
+
+func (b *BootROM) overlaid(addr uint16) bool {
+	return int(addr) < len(b.image) && (addr < 0x0100 || addr >= 0x0200)
+}
+
+func (b *BootROM) Unmap() {
+	// Put the cartridge back in place of the boot ROM
+	if b.Mapped {
+		b.Mapped = false
+		b.bus.Map(REGION_ROM0, b.cartridge)
+	}
+}
+
+func (b *BootROM) Underlay(d Device) bool {
+	// A cartridge inserted while the boot ROM runs goes underneath it
+	if b.Mapped {
+		b.cartridge = d
+	}
+	return b.Mapped
+}
+
+func (b *BootROM) Bank(addr uint16) int {
+	if b.Mapped && b.overlaid(addr) {
+		return 0
+	}
+	return device_bank(b.cartridge, addr)
+}
+
+func (b *BootROM) Read(addr uint16) byte {
+	if addr == REG_BANK {
+		return 0xff
+	}
+	if b.Mapped && b.overlaid(addr) {
+		return b.image[addr]
+	}
+	return b.cartridge.Read(addr)
+}
+
+func (b *BootROM) Write(addr uint16, v byte) {
+	if addr == REG_BANK {
+		b.Unmap()
+		return
+	}
+	b.cartridge.Write(addr, v) // mapper registers still respond while the boot ROM runs
+}
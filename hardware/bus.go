@@ -2,49 +2,220 @@ package hardware
 
 import (
 	"fmt"
-	"log"
 	"strings"
 )
 
 const MEM_SIZE = 0xFFFF + 1
 
-type Memory [MEM_SIZE]byte
+const (
+	REG_P1   = 0xFF00 // Joypad
+	REG_DIV  = 0xFF04 // Divider register
+	REG_KEY1 = 0xFF4D // CGB speed switch
+)
+
+// A Device backs one or more regions of the address space. Addresses are passed
+// through unchanged, a device mapped at 0xA000 sees 0xA000-0xBFFF
+type Device interface {
+	Read(addr uint16) byte
+	Write(addr uint16, b byte)
+}
+
+type REGION uint8
+
+const (
+	REGION_ROM0     REGION = iota // 0x0000-0x3FFF cartridge ROM bank 0
+	REGION_ROMX                   // 0x4000-0x7FFF switchable cartridge ROM bank
+	REGION_VRAM                   // 0x8000-0x9FFF video RAM
+	REGION_ERAM                   // 0xA000-0xBFFF external cartridge RAM
+	REGION_WRAM                   // 0xC000-0xDFFF work RAM
+	REGION_ECHO                   // 0xE000-0xFDFF mirror of 0xC000-0xDDFF
+	REGION_OAM                    // 0xFE00-0xFE9F object attribute memory
+	REGION_UNUSABLE               // 0xFEA0-0xFEFF prohibited area
+	REGION_IO                     // 0xFF00-0xFF7F I/O registers
+	REGION_HRAM                   // 0xFF80-0xFFFE high RAM
+	REGION_IE                     // 0xFFFF interrupt enable register
+	REGION_COUNT
+)
+
+var region_names = []string{"ROM0", "ROMX", "VRAM", "ERAM", "WRAM", "ECHO", "OAM", "UNUSABLE", "IO", "HRAM", "IE"}
+
+func (r REGION) String() string {
+	if r < REGION_COUNT {
+		return region_names[r]
+	}
+	return fmt.Sprintf("REGION(%d)", r)
+}
+
+// First address of each region, the last is one below the start of the next
+var region_starts = [REGION_COUNT + 1]uint32{0x0000, 0x4000, 0x8000, 0xA000, 0xC000, 0xE000, 0xFE00, 0xFEA0, 0xFF00, 0xFF80, 0xFFFF, 0x10000}
+
+func (r REGION) Start() uint16 {
+	return uint16(region_starts[r])
+}
+
+func (r REGION) Size() int {
+	return int(region_starts[r+1] - region_starts[r])
+}
 
-var busInstance *Memory
+// Region of every 256 byte page below 0xFE00, the last two pages are split further
+var page_regions = func() (pages [256]REGION) {
+	r := REGION(0)
+	for p := range pages {
+		for uint32(p)<<8 >= region_starts[r+1] {
+			r++
+		}
+		pages[p] = r
+	}
+	return
+}()
 
-func GetBus() *Memory {
-	if busInstance != nil {
-		return busInstance
+func Decode(addr uint16) REGION {
+	// Return the region an address belongs to
+	if addr < 0xFE00 {
+		return page_regions[addr>>8]
 	}
+	switch {
+	case addr < 0xFEA0:
+		return REGION_OAM
+	case addr < 0xFF00:
+		return REGION_UNUSABLE
+	case addr < 0xFF80:
+		return REGION_IO
+	case addr < 0xFFFF:
+		return REGION_HRAM
+	}
+	return REGION_IE
+}
 
-	log.Println("Creating Bus Instance")
-	busInstance = &Memory{}
-	return busInstance
+type Bus struct {
+	devices [REGION_COUNT]Device
+	io      *IO
 }
 
-func (m *Memory) Read(addr uint16) byte {
-	return m[addr]
+func NewBus() *Bus {
+	// Create a DMG address space with plain RAM behind every region. Until a cartridge
+	// is inserted ROM0 and ROMX are writable so programs can be poked in directly
+	b := &Bus{io: &IO{}}
+	for r := REGION(0); r < REGION_COUNT; r++ {
+		b.devices[r] = NewRAM(r.Start(), r.Size())
+	}
+	b.devices[REGION_ECHO] = &Mirror{Bus: b, Offset: 0x2000}
+	b.devices[REGION_UNUSABLE] = Unmapped(0x00)
+	b.devices[REGION_IO] = b.io
+	return b
+}
+
+// An Overlay sits in front of the device backing a region, like the boot ROM over bank 0
+type Overlay interface {
+	Device
+	Underlay(d Device) bool // replace the device underneath, false once the overlay is gone
 }
 
-func (m *Memory) Write(addr uint16, b byte) {
-	m[addr] = b
+// A Banked device reports which ROM bank it maps at an address in 0x0000-0x7FFF
+type Banked interface {
+	Bank(addr uint16) int
 }
 
-func (m *Memory) WriteBytes(code []byte, location uint16) {
-	for k, b := range code {
-		m[uint16(k)+location] = b
+func (b *Bus) Map(r REGION, d Device) {
+	// Back a region with a device, replacing whatever was mapped there or, while an
+	// overlay is in place, the device underneath it
+	if o, ok := b.devices[r].(Overlay); ok && o.Underlay(d) {
+		return
 	}
+	b.devices[r] = d
+}
+
+func (b *Bus) Device(r REGION) Device {
+	return b.devices[r]
+}
+
+func (b *Bus) MapIO(addr uint16, d Device) {
+	// Hand reads and writes of a single I/O register in 0xFF00-0xFF7F to a device
+	b.io.handlers[addr-0xFF00] = d
+}
+
+func (b *Bus) Read(addr uint16) byte {
+	return b.devices[Decode(addr)].Read(addr)
+}
+
+func (b *Bus) Write(addr uint16, v byte) {
+	b.devices[Decode(addr)].Write(addr, v)
 }
 
-func (m *Memory) String() string {
+func (b *Bus) WriteBytes(code []byte, location uint16) {
+	for k, v := range code {
+		b.Write(uint16(k)+location, v)
+	}
+}
+
+func (b *Bus) String() string {
 	rep := ""
 	for j := 0; j < MEM_SIZE; j += 16 {
 		rep += fmt.Sprintf("%04X: ", j)
 		row := [16]string{}
 		for i := 0; i < 16; i++ {
-			row[i] = fmt.Sprintf("%02X", m[j+i])
+			row[i] = fmt.Sprintf("%02X", b.Read(uint16(j+i)))
 		}
 		rep += fmt.Sprintf("%s\t%s\n", strings.Join(row[:8], " "), strings.Join(row[8:], " "))
 	}
 	return rep
 }
+
+type RAM struct {
+	Base uint16
+	Data []byte
+}
+
+func NewRAM(base uint16, size int) *RAM {
+	return &RAM{Base: base, Data: make([]byte, size)}
+}
+
+func (r *RAM) Read(addr uint16) byte {
+	return r.Data[addr-r.Base]
+}
+
+func (r *RAM) Write(addr uint16, b byte) {
+	r.Data[addr-r.Base] = b
+}
+
+type Mirror struct {
+	Bus    *Bus
+	Offset uint16 // distance down to the mirrored addresses
+}
+
+func (m *Mirror) Read(addr uint16) byte {
+	return m.Bus.Read(addr - m.Offset)
+}
+
+func (m *Mirror) Write(addr uint16, b byte) {
+	m.Bus.Write(addr-m.Offset, b)
+}
+
+// Unmapped reads back as a constant and ignores writes
+type Unmapped byte
+
+func (u Unmapped) Read(addr uint16) byte {
+	return byte(u)
+}
+
+func (u Unmapped) Write(addr uint16, b byte) {}
+
+type IO struct {
+	regs     [0x80]byte
+	handlers [0x80]Device
+}
+
+func (io *IO) Read(addr uint16) byte {
+	if d := io.handlers[addr-0xFF00]; d != nil {
+		return d.Read(addr)
+	}
+	return io.regs[addr-0xFF00]
+}
+
+func (io *IO) Write(addr uint16, b byte) {
+	if d := io.handlers[addr-0xFF00]; d != nil {
+		d.Write(addr, b)
+		return
+	}
+	io.regs[addr-0xFF00] = b
+}
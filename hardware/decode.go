@@ -0,0 +1,270 @@
+package hardware
+
+import "strings"
+
+type ADDRESS_MODE uint8
+
+const (
+	MODE_IMPLIED   ADDRESS_MODE = iota // registers only, or no operands
+	MODE_IMMEDIATE                     // n8, n16, e8 or a16 operand used as a value
+	MODE_INDIRECT                      // memory at [BC], [DE], [HL], [HL+] or [HL-]
+	MODE_DIRECT                        // memory at [a16]
+	MODE_HIGH                          // memory at [a8] or [C] in page 0xFF00
+	MODE_STACK                         // memory at the stack pointer
+)
+
+type REGISTER_SET uint16
+
+const (
+	R_A REGISTER_SET = 1 << iota
+	R_F
+	R_B
+	R_C
+	R_D
+	R_E
+	R_H
+	R_L
+	R_SP
+	R_PC
+)
+
+var register_names = []string{"A", "F", "B", "C", "D", "E", "H", "L", "SP", "PC"}
+
+func (r REGISTER_SET) Has(regs REGISTER_SET) bool {
+	return r&regs == regs
+}
+
+func (r REGISTER_SET) String() string {
+	names := []string{}
+	for i, name := range register_names {
+		if r&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+type DECODE_INFO struct {
+	Mode     ADDRESS_MODE
+	Operands uint8 // number of immediate operand bytes
+	Reads    REGISTER_SET
+	Writes   REGISTER_SET
+	Memory   string // operand naming the memory location, e.g. "[HL+]"
+	Pushes   bool   // writes below the stack pointer
+}
+
+var register_operands = map[string]REGISTER_SET{
+	"A": R_A, "B": R_B, "C": R_C, "D": R_D, "E": R_E, "H": R_H, "L": R_L,
+	"AF": R_A | R_F, "BC": R_B | R_C, "DE": R_D | R_E, "HL": R_H | R_L, "SP": R_SP,
+}
+
+var conditions = map[string]bool{"NZ": true, "Z": true, "NC": true, "C": true}
+
+func decode_operand(info *DECODE_INFO, arg string, write bool) {
+	// Accumulate the registers and addressing mode used by a single operand
+	if r, ok := register_operands[arg]; ok {
+		if write {
+			info.Writes |= r
+		} else {
+			info.Reads |= r
+		}
+		return
+	}
+
+	switch arg {
+	case "n8", "e8":
+		info.Mode = MODE_IMMEDIATE
+		info.Operands = 1
+	case "n16", "a16":
+		info.Mode = MODE_IMMEDIATE
+		info.Operands = 2
+	case "SP+e8":
+		info.Mode = MODE_IMMEDIATE
+		info.Operands = 1
+		info.Reads |= R_SP
+	case "[BC]", "[DE]", "[HL]":
+		info.Mode = MODE_INDIRECT
+		info.Reads |= register_operands[arg[1:3]]
+	case "[HL+]", "[HL-]":
+		info.Mode = MODE_INDIRECT
+		info.Reads |= R_H | R_L
+		info.Writes |= R_H | R_L
+	case "[a16]":
+		info.Mode = MODE_DIRECT
+		info.Operands = 2
+	case "[a8]":
+		info.Mode = MODE_HIGH
+		info.Operands = 1
+	case "[C]":
+		info.Mode = MODE_HIGH
+		info.Reads |= R_C
+	}
+	if strings.HasPrefix(arg, "[") {
+		info.Memory = arg
+	}
+}
+
+func decode_mneumonic(mneumonic, flags string) (info DECODE_INFO) {
+	// Derive addressing mode and register usage from the instruction table entry
+	name, operands, _ := strings.Cut(mneumonic, " ")
+	args := []string{}
+	if operands != "" {
+		args = strings.Split(operands, ",")
+	}
+	if strings.Trim(flags, "-") != "" {
+		info.Writes |= R_F
+	}
+
+	switch name {
+	case "ld", "ldh":
+		decode_operand(&info, args[1], false)
+		decode_operand(&info, args[0], true)
+	case "add", "adc", "sub", "sbc", "and", "xor", "or", "cp":
+		if len(args) == 2 { // 16 bit add to HL or SP
+			decode_operand(&info, args[1], false)
+			decode_operand(&info, args[0], false)
+			decode_operand(&info, args[0], true)
+			break
+		}
+		info.Reads |= R_A
+		decode_operand(&info, args[0], false)
+		if name != "cp" {
+			info.Writes |= R_A
+		}
+		if name == "adc" || name == "sbc" {
+			info.Reads |= R_F
+		}
+	case "inc", "dec":
+		decode_operand(&info, args[0], false)
+		decode_operand(&info, args[0], true)
+	case "rlca", "rrca", "rla", "rra", "cpl":
+		info.Reads |= R_A
+		info.Writes |= R_A
+		if name == "rla" || name == "rra" {
+			info.Reads |= R_F
+		}
+	case "daa", "ccf":
+		info.Reads |= R_F
+		if name == "daa" {
+			info.Reads |= R_A
+			info.Writes |= R_A
+		}
+	case "jr", "jp", "call", "ret", "reti", "rst":
+		if len(args) > 0 && conditions[args[0]] {
+			info.Reads |= R_F
+			args = args[1:]
+		}
+		if len(args) > 0 && args[0] != "HL" && name != "rst" {
+			decode_operand(&info, args[0], false)
+		}
+		if len(args) > 0 && args[0] == "HL" {
+			info.Reads |= R_H | R_L
+		}
+		if name == "jr" || name == "call" || name == "rst" {
+			info.Reads |= R_PC
+		}
+		if name == "call" || name == "ret" || name == "reti" || name == "rst" {
+			info.Mode = MODE_STACK
+			info.Reads |= R_SP
+			info.Writes |= R_SP
+			info.Pushes = name == "call" || name == "rst"
+		}
+		info.Writes |= R_PC
+	case "push", "pop":
+		info.Mode = MODE_STACK
+		info.Reads |= R_SP
+		info.Writes |= R_SP
+		info.Pushes = name == "push"
+		decode_operand(&info, args[0], name == "pop")
+	case "rlc", "rrc", "rl", "rr", "sla", "sra", "swap", "srl":
+		decode_operand(&info, args[0], false)
+		decode_operand(&info, args[0], true)
+		if name == "rl" || name == "rr" {
+			info.Reads |= R_F
+		}
+	case "bit":
+		decode_operand(&info, args[1], false)
+	case "res", "set":
+		decode_operand(&info, args[1], false)
+		decode_operand(&info, args[1], true)
+	}
+	return
+}
+
+var decode_table = build_decode_table(&Operations)
+var prefixed_decode_table = build_decode_table(&Prefixed_Operations)
+
+func build_decode_table(operations *[256]OPERATION) (table [256]DECODE_INFO) {
+	for op, o := range operations {
+		table[op] = decode_mneumonic(o.Mneumonic, o.Flags)
+	}
+	return
+}
+
+// Registers an effective address is formed from, as they were before the instruction ran
+type decode_registers struct {
+	bc, de, hl, sp uint16
+	c              uint8
+}
+
+func (c *CPU) decode_registers() decode_registers {
+	return decode_registers{c.read_r16(BC), c.read_r16(DE), c.read_r16(HL), c.SP, c.C}
+}
+
+func (c *CPU) decode(addr uint16, before decode_registers) {
+	// Record what the instruction fetched from addr did, from the operand bytes it read
+	// and the registers before it ran, so the bus sees no accesses besides its own
+	e := &c.ExecInfo
+	op := OPCODE(e.Opcode)
+	info := decode_table[op]
+	operands := e.operands[:e.fetched]
+	e.Address = addr
+	e.Mneumonic = Operations[op].Mneumonic
+	if op == 0xCB {
+		cb := operands[0]
+		operands = operands[1:]
+		info = prefixed_decode_table[cb]
+		e.Opcode = 0xCB00 | uint16(cb)
+		e.Mneumonic = Prefixed_Operations[cb].Mneumonic
+	}
+	e.AddressMode = info.Mode
+	e.Reads = info.Reads
+	e.Writes = info.Writes
+
+	e.Dispatch = false
+	e.Operands = [2]byte{}
+	e.OperandCount = uint8(copy(e.Operands[:], operands))
+	imm := uint16(0)
+	if len(operands) > 0 {
+		imm = uint16(operands[0])
+	}
+	if len(operands) == 2 {
+		imm |= uint16(operands[1]) << 8
+	}
+
+	e.Effective = 0
+	switch info.Mode {
+	case MODE_INDIRECT:
+		switch info.Memory {
+		case "[BC]":
+			e.Effective = before.bc
+		case "[DE]":
+			e.Effective = before.de
+		default:
+			e.Effective = before.hl
+		}
+	case MODE_DIRECT:
+		e.Effective = imm
+	case MODE_HIGH:
+		if info.Memory == "[C]" {
+			e.Effective = 0xff00 | uint16(before.c)
+		} else {
+			e.Effective = 0xff00 | imm
+		}
+	case MODE_STACK:
+		e.Effective = before.sp
+		if info.Pushes {
+			e.Effective = before.sp - 2 // lowest address written
+		}
+	}
+}
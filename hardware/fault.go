@@ -0,0 +1,38 @@
+package hardware
+
+import "fmt"
+
+type Fault struct {
+	Opcode OPCODE
+	PC     uint16 // address of the offending opcode
+	Bank   int    // ROM bank mapped at PC
+	Cycle  uint64 // T-states elapsed when the CPU locked up
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("illegal opcode %02X at %02X:%04X after %d T-states", f.Opcode, f.Bank, f.PC, f.Cycle)
+}
+
+func (c *CPU) rom_bank(addr uint16) int {
+	if addr >= 0x8000 {
+		return 0
+	}
+	return device_bank(c.Bus.Device(Decode(addr)), addr)
+}
+
+func device_bank(d Device, addr uint16) int {
+	// Ask the mapper, without one the switchable window always holds bank 1
+	if b, ok := d.(Banked); ok {
+		return b.Bank(addr)
+	}
+	return int(addr >> 14 & 1)
+}
+
+func (c *CPU) lock(op OPCODE) {
+	// Hang the CPU until reset and report the fault to the host
+	c.Status.Locked = true
+	c.Fault = &Fault{Opcode: op, PC: c.PC, Bank: c.rom_bank(c.PC), Cycle: c.Cycles}
+	if c.OnFault != nil {
+		c.OnFault(c.Fault)
+	}
+}
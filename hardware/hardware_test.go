@@ -1,11 +1,14 @@
 package hardware
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCPUAdd8(t *testing.T) {
-	cpu := GetCPU()
+	t.Parallel()
+	cpu := NewCPU()
 
 	// result is 3 and both carry flags are 0
 	res, carry := cpu.add8(0x01, 0x01, 1)
@@ -45,7 +48,8 @@ func TestCPUAdd8(t *testing.T) {
 }
 
 func TestCPUSub8(t *testing.T) {
-	cpu := GetCPU()
+	t.Parallel()
+	cpu := NewCPU()
 
 	res, carry := cpu.sub8(0x01, 0x01, 1)
 	if res != 0xff || carry != 0b01110000 {
@@ -84,7 +88,8 @@ func TestCPUSub8(t *testing.T) {
 }
 
 func TestCPUADDB(t *testing.T) {
-	cpu := GetCPU()
+	t.Parallel()
+	cpu := NewCPU()
 
 	cpu.A = 0xff
 	cpu.B = 0xff
@@ -104,7 +109,8 @@ func TestCPUADDB(t *testing.T) {
 }
 
 func TestCPUINCB(t *testing.T) {
-	cpu := GetCPU()
+	t.Parallel()
+	cpu := NewCPU()
 
 	cpu.B = 0x00
 	cpu.F = 0x00
@@ -128,47 +134,880 @@ func TestCPUINCB(t *testing.T) {
 }
 
 func TestAddByteToStackPointer(t *testing.T) {
-	cpu := GetCPU()
+	t.Parallel()
+	cpu := NewCPU()
 
 	cpu.SP = 0xff00
-	cpu.Bus[cpu.PC+1] = 0x01
+	cpu.Bus.Write(cpu.PC+1, 0x01)
 	cpu.ADD_SP_e8()
 	if cpu.SP != 0xff01 || cpu.F != 0x00 {
 		t.Fatalf("Stack pointer is %04x and flags are %02x, wanted 0xff01 and 0x00", cpu.SP, cpu.F)
 	}
 
 	cpu.SP = 0xff00
-	cpu.Bus[cpu.PC+1] = 0xff // -1
+	cpu.Bus.Write(cpu.PC+1, 0xff) // -1
 	cpu.ADD_SP_e8()
 	if cpu.SP != 0xfeff || cpu.F != 0x00 {
 		t.Fatalf("Stack pointer is %04x and flags are %02x, wanted 0xff01 and 0x00", cpu.SP, cpu.F)
 	}
 
 	cpu.SP = 0x00ff
-	cpu.Bus[cpu.PC+1] = 0x01
+	cpu.Bus.Write(cpu.PC+1, 0x01)
 	cpu.ADD_SP_e8()
 	if cpu.SP != 0x0100 || cpu.F != 0x30 {
 		t.Fatalf("Stack pointer is %04x and flags are %02x, wanted 0x0100 and 0x30", cpu.SP, cpu.F)
 	}
 
 	cpu.SP = 0x000e
-	cpu.Bus[cpu.PC+1] = 0x03
+	cpu.Bus.Write(cpu.PC+1, 0x03)
 	cpu.ADD_SP_e8()
 	if cpu.SP != 0x0011 || cpu.F != 0x20 {
 		t.Fatalf("Stack pointer is %04x and flags are %02x, wanted 0x0011 and 0x20", cpu.SP, cpu.F)
 	}
 
 	cpu.SP = 0x00f0
-	cpu.Bus[cpu.PC+1] = 0x16
+	cpu.Bus.Write(cpu.PC+1, 0x16)
 	cpu.ADD_SP_e8()
 	if cpu.SP != 0x0106 || cpu.F != 0x10 {
 		t.Fatalf("Stack pointer is %04x and flags are %02x, wanted 0x0106 and 0x10", cpu.SP, cpu.F)
 	}
 
 	cpu.SP = 0x00f0
-	cpu.Bus[cpu.PC+1] = 0xef // -17
+	cpu.Bus.Write(cpu.PC+1, 0xef) // -17
 	cpu.ADD_SP_e8()
 	if cpu.SP != 0x00df || cpu.F != 0x10 {
 		t.Fatalf("Stack pointer is %04x and flags are %02x, wanted 0x00df and 0x10", cpu.SP, cpu.F)
 	}
 }
+
+func TestInterruptDispatch(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.PC = 0x1234
+	cpu.SP = 0xd000
+	cpu.Status.Interrupt_Enabled = true
+	cpu.Bus.Write(REG_IE, INT_TIMER|INT_SERIAL)
+	cpu.Bus.Write(REG_IF, INT_SERIAL|INT_TIMER|INT_VBLANK)
+
+	before := cpu.Cycles
+	cpu.Step()
+	if cpu.Cycles-before != 20 {
+		t.Fatalf("dispatch took %d T-states, wanted 20", cpu.Cycles-before)
+	}
+	if cpu.PC != 0x0050 || cpu.SP != 0xcffe {
+		t.Fatalf("PC is %04x and SP is %04x, wanted 0050 and cffe", cpu.PC, cpu.SP)
+	}
+	if ret := cpu.pop(); ret != 0x1234 {
+		t.Fatalf("pushed return address is %04x, wanted 1234", ret)
+	}
+	if cpu.Status.Interrupt_Enabled {
+		t.Fatalf("IME still set after dispatch")
+	}
+	if f := cpu.Bus.Read(REG_IF); f != INT_SERIAL|INT_VBLANK {
+		t.Fatalf("IF is %02x, wanted %02x", f, INT_SERIAL|INT_VBLANK)
+	}
+}
+
+func TestInterruptEnableDelay(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{0xfb, 0x00, 0x00}, 0xc000) // ei, nop, nop
+	cpu.PC = 0xc000
+	cpu.SP = 0xd000
+	cpu.Status.Interrupt_Enabled = false
+	cpu.Bus.Write(REG_IE, INT_VBLANK)
+	cpu.Bus.Write(REG_IF, INT_VBLANK)
+
+	cpu.Step() // ei
+	if cpu.Status.Interrupt_Enabled {
+		t.Fatalf("IME set immediately after EI")
+	}
+	cpu.Step() // nop, IME is set afterwards
+	if cpu.PC != 0xc002 || !cpu.Status.Interrupt_Enabled {
+		t.Fatalf("PC is %04x and IME is %v, wanted c002 and true", cpu.PC, cpu.Status.Interrupt_Enabled)
+	}
+	cpu.Step() // dispatch
+	if cpu.PC != 0x0040 {
+		t.Fatalf("PC is %04x, wanted 0040", cpu.PC)
+	}
+
+	cpu.Bus.WriteBytes([]byte{0xfb, 0xf3, 0x00}, 0xc000) // ei, di, nop
+	cpu.PC = 0xc000
+	cpu.Bus.Write(REG_IF, INT_VBLANK)
+	cpu.Step()
+	cpu.Step()
+	cpu.Step()
+	if cpu.PC != 0xc003 || cpu.Status.Interrupt_Enabled {
+		t.Fatalf("PC is %04x and IME is %v, wanted c003 and false", cpu.PC, cpu.Status.Interrupt_Enabled)
+	}
+}
+
+func TestReturnFromInterrupt(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.SP = 0xd000
+	cpu.push(0x4321)
+	cpu.Status.Interrupt_Enabled = false
+	cpu.RETI()
+	if cpu.PC != 0x4321 || !cpu.Status.Interrupt_Enabled {
+		t.Fatalf("PC is %04x and IME is %v, wanted 4321 and true", cpu.PC, cpu.Status.Interrupt_Enabled)
+	}
+}
+
+func TestHaltWakeup(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{0x76, 0x3c}, 0xc000) // halt, inc A
+	cpu.PC = 0xc000
+	cpu.SP = 0xd000
+	cpu.A = 0x00
+	cpu.Status.Interrupt_Enabled = false
+	cpu.Bus.Write(REG_IE, INT_TIMER)
+	cpu.Bus.Write(REG_IF, 0x00)
+
+	cpu.Step()
+	cpu.Step()
+	cpu.Step()
+	if !cpu.Status.Halted || cpu.PC != 0xc001 || cpu.A != 0x00 {
+		t.Fatalf("halted is %v, PC is %04x and A is %02x, wanted true, c001 and 00", cpu.Status.Halted, cpu.PC, cpu.A)
+	}
+
+	// IME is not set, execution resumes without servicing the interrupt
+	cpu.Request_interrupt(INT_TIMER)
+	cpu.Step()
+	if cpu.Status.Halted || cpu.PC != 0xc002 || cpu.A != 0x01 {
+		t.Fatalf("halted is %v, PC is %04x and A is %02x, wanted false, c002 and 01", cpu.Status.Halted, cpu.PC, cpu.A)
+	}
+
+	// IME is set, the interrupt is serviced and returns to the instruction after HALT
+	cpu.PC = 0xc000
+	cpu.Bus.Write(REG_IF, 0x00)
+	cpu.Status.Interrupt_Enabled = true
+	cpu.Step()
+	cpu.Request_interrupt(INT_TIMER)
+	cpu.Step()
+	if cpu.PC != 0x0050 || cpu.pop() != 0xc001 {
+		t.Fatalf("PC is %04x, wanted 0050 with return address c001", cpu.PC)
+	}
+}
+
+func TestHaltBug(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{0x76, 0x3c, 0x00}, 0xc000) // halt, inc A, nop
+	cpu.PC = 0xc000
+	cpu.A = 0x00
+	cpu.Status.Interrupt_Enabled = false
+	cpu.Bus.Write(REG_IE, INT_TIMER)
+	cpu.Bus.Write(REG_IF, INT_TIMER)
+
+	cpu.Step()
+	if cpu.Status.Halted {
+		t.Fatalf("CPU halted with an interrupt pending and IME not set")
+	}
+	cpu.Step()
+	cpu.Step()
+	if cpu.PC != 0xc002 || cpu.A != 0x02 {
+		t.Fatalf("PC is %04x and A is %02x, wanted c002 and 02", cpu.PC, cpu.A)
+	}
+}
+
+func TestHaltBugDispatch(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{0xfb, 0x76, 0x3c}, 0xc000) // ei, halt, inc A
+	cpu.Bus.WriteBytes([]byte{0x04, 0x00}, 0x0050)       // inc B, nop
+	cpu.PC = 0xc000
+	cpu.SP = 0xd000
+	cpu.B = 0x00
+	cpu.Status.Interrupt_Enabled = false
+	cpu.Bus.Write(REG_IE, INT_TIMER)
+	cpu.Bus.Write(REG_IF, INT_TIMER)
+
+	// IME is still clear when HALT runs, so it takes the HALT bug path before the interrupt
+	cpu.Step()
+	cpu.Step()
+	cpu.Step()
+	if cpu.PC != 0x0050 || cpu.read_mem(cpu.SP) != 0x01 || cpu.read_mem(cpu.SP+1) != 0xc0 {
+		t.Fatalf("PC is %04x, wanted 0050 with return address c001", cpu.PC)
+	}
+	cpu.Step()
+	cpu.Step()
+	if cpu.PC != 0x0052 || cpu.B != 0x01 {
+		t.Fatalf("PC is %04x and B is %02x, wanted 0052 and 01 with the handler's first instruction run once", cpu.PC, cpu.B)
+	}
+}
+
+func TestStop(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{0x10, 0x00, 0x3c}, 0xc000) // stop, inc A
+	cpu.PC = 0xc000
+	cpu.A = 0x00
+	cpu.Bus.Write(REG_P1, 0x20) // select the direction row, the input lines are not writable
+	cpu.Bus.Write(REG_DIV, 0xab)
+
+	cpu.Step()
+	cpu.Step()
+	if !cpu.Status.Stopped || cpu.PC != 0xc002 || cpu.A != 0x00 {
+		t.Fatalf("stopped is %v, PC is %04x and A is %02x, wanted true, c002 and 00", cpu.Status.Stopped, cpu.PC, cpu.A)
+	}
+	if div := cpu.Bus.Read(REG_DIV); div != 0x00 {
+		t.Fatalf("DIV is %02x, wanted 00", div)
+	}
+
+	cpu.Joypad.Press(BUTTON_START) // on the row that is not selected
+	cpu.Step()
+	if !cpu.Status.Stopped {
+		t.Fatalf("woke up on a button of the unselected row")
+	}
+	cpu.Joypad.Press(BUTTON_DOWN)
+	cpu.Step()
+	if cpu.Status.Stopped || cpu.A != 0x01 {
+		t.Fatalf("stopped is %v and A is %02x, wanted false and 01", cpu.Status.Stopped, cpu.A)
+	}
+}
+
+func TestStopSpeedSwitch(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPUModel(MODEL_CGB)
+
+	cpu.Bus.WriteBytes([]byte{0x10, 0x00}, 0xc000)
+	cpu.PC = 0xc000
+	cpu.Bus.Write(REG_KEY1, 0x80) // the speed bit is not writable
+	if key1 := cpu.Bus.Read(REG_KEY1); key1 != 0x7e || cpu.Speed.Double() {
+		t.Fatalf("KEY1 is %02x after writing 80, wanted 7e", key1)
+	}
+	cpu.Bus.Write(REG_KEY1, 0x01)
+
+	cpu.Step()
+	if cpu.Status.Stopped || cpu.PC != 0xc002 {
+		t.Fatalf("stopped is %v and PC is %04x, wanted false and c002", cpu.Status.Stopped, cpu.PC)
+	}
+	if key1 := cpu.Bus.Read(REG_KEY1); key1 != 0xfe || !cpu.Speed.Double() {
+		t.Fatalf("KEY1 is %02x, wanted fe", key1)
+	}
+	cpu.Reset(MODEL_CGB)
+	if key1 := cpu.Bus.Read(REG_KEY1); key1 != 0x7e {
+		t.Fatalf("KEY1 is %02x after reset, wanted 7e", key1)
+	}
+}
+
+func TestJoypad(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+	j := cpu.Joypad
+	if got := cpu.Bus.Read(REG_P1); got != 0xcf {
+		t.Errorf("P1 reads %02x after boot, wanted cf", got)
+	}
+	cpu.Bus.Write(REG_IF, 0x00)
+	j.Press(BUTTON_A | BUTTON_LEFT)
+	cpu.Bus.Write(REG_P1, 0x10) // action row
+	if got := cpu.Bus.Read(REG_P1); got != 0xde {
+		t.Errorf("P1 reads %02x with A held, wanted de", got)
+	}
+	cpu.Bus.Write(REG_P1, 0x20) // direction row
+	if got := cpu.Bus.Read(REG_P1); got != 0xed {
+		t.Errorf("P1 reads %02x with left held, wanted ed", got)
+	}
+	cpu.Bus.Write(REG_P1, 0x30)
+	if got := cpu.Bus.Read(REG_P1); got != 0xff {
+		t.Errorf("P1 reads %02x with no row selected, wanted ff", got)
+	}
+	cpu.Bus.Write(REG_P1, 0x00) // the guest cannot pull the lines low
+	j.Release(BUTTON_A | BUTTON_LEFT)
+	if got := cpu.Bus.Read(REG_P1); got != 0xcf {
+		t.Errorf("P1 reads %02x after release, wanted cf", got)
+	}
+
+	// a line going low requests the interrupt on the next tick
+	j.Tick(4)
+	if cpu.Bus.Read(REG_IF)&INT_JOYPAD != 0 {
+		t.Fatalf("joypad interrupt requested with no button pressed")
+	}
+	j.Press(BUTTON_START)
+	j.Tick(4)
+	if cpu.Bus.Read(REG_IF)&INT_JOYPAD == 0 {
+		t.Fatalf("no joypad interrupt after pressing start")
+	}
+}
+
+// Peripheral that ends Run once a number of T-states have gone by
+type terminator struct {
+	cpu   *CPU
+	after uint64
+}
+
+func (p *terminator) Tick(t_states uint8) {
+	if p.cpu.Cycles >= p.after {
+		p.cpu.Terminate()
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+	cpu.Bus.WriteBytes([]byte{0x3c, 0x18, 0xfd}, 0xc000) // inc A, jr -3
+	cpu.PC = 0xc000
+
+	// from a peripheral, Run stops after the instruction in progress
+	cpu.Attach(&terminator{cpu, 1000})
+	if err := cpu.Run(); err != nil || cpu.Cycles < 1000 || cpu.Cycles > 1012 {
+		t.Fatalf("Run returned %v after %d T-states, wanted nil after 1000-1012", err, cpu.Cycles)
+	}
+	cpu.Peripherals = cpu.Peripherals[:len(cpu.Peripherals)-1]
+
+	// from another goroutine, run under -race
+	done := make(chan error)
+	go func() { done <- cpu.Run() }()
+	cpu.Terminate()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after Terminate")
+	}
+
+	// a Terminate made while nothing runs ends the next Run straight away
+	cpu.Terminate()
+	cycles := cpu.Cycles
+	if err := cpu.Run(); err != nil || cpu.Cycles != cycles {
+		t.Fatalf("Run returned %v after %d T-states, wanted nil after none", err, cpu.Cycles-cycles)
+	}
+}
+
+func TestRunUntil(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{0x3c, 0x3c, 0x10, 0x00}, 0xc000) // inc A, inc A, stop
+	cpu.PC = 0xc000
+	cpu.A = 0x00
+	cpu.RunUntil(0xc002)
+	if cpu.PC != 0xc002 || cpu.A != 0x02 || cpu.Status.Stopped {
+		t.Fatalf("PC is %04x, A is %02x and stopped is %v, wanted c002, 02 and false", cpu.PC, cpu.A, cpu.Status.Stopped)
+	}
+}
+
+func TestCycleCounter(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{
+		0x00,       // nop
+		0xaf,       // xor A
+		0x20, 0x05, // jr nz,e8 (not taken)
+		0xcd, 0x00, 0xc1, // call a16
+		0x28, 0x10, // jr z,e8 (taken)
+	}, 0xc000)
+	cpu.Bus.WriteBytes([]byte{
+		0xc0,       // ret nz (not taken)
+		0xcb, 0x37, // swap A
+		0xc8, // ret z (taken)
+	}, 0xc100)
+	cpu.PC = 0xc000
+	cpu.SP = 0xd000
+	cpu.Status.Interrupt_Enabled = false
+
+	want := []uint64{4, 4, 8, 24, 8, 8, 20, 12}
+	for i, w := range want {
+		before := cpu.Cycles
+		cpu.Step()
+		if got := cpu.Cycles - before; got != w {
+			t.Fatalf("instruction %d took %d T-states, wanted %d", i, got, w)
+		}
+	}
+}
+
+type busWatcher struct {
+	bus  *Bus
+	addr uint16
+	seen []byte
+}
+
+func (p *busWatcher) Tick(t_states uint8) {
+	// Record the value at addr at the start of every M-cycle
+	p.seen = append(p.seen, p.bus.Read(p.addr))
+}
+
+func TestMidInstructionTiming(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+	watcher := &busWatcher{bus: cpu.Bus, addr: 0xcfff}
+	cpu.Attach(watcher)
+
+	// the stack writes of push happen after an internal delay
+	cpu.Bus.WriteBytes([]byte{0xc5}, 0xc000) // push BC
+	cpu.Bus.Write(0xcfff, 0x00)
+	cpu.PC = 0xc000
+	cpu.SP = 0xd000
+	cpu.write_r16(BC, 0x1234)
+	cpu.Step()
+	want := []byte{0x00, 0x00, 0x00, 0x12}
+	if string(watcher.seen) != string(want) {
+		t.Fatalf("[SP-1] over the M-cycles of push is % x, wanted % x", watcher.seen, want)
+	}
+
+	// the operand of ld A,[a16] is read in the last M-cycle
+	watcher.seen = nil
+	cpu.Bus.WriteBytes([]byte{0xfa, 0xff, 0xcf}, 0xc000) // ld A,[a16]
+	cpu.PC = 0xc000
+	cpu.Step()
+	if len(watcher.seen) != 4 || cpu.A != 0x12 {
+		t.Fatalf("A is %02x after %d M-cycles, wanted 12 after 4", cpu.A, len(watcher.seen))
+	}
+}
+
+func TestInstructionTiming(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	conditional := map[OPCODE]bool{
+		0x20: true, 0x28: true, 0x30: true, 0x38: true,
+		0xC0: true, 0xC2: true, 0xC4: true, 0xC8: true, 0xCA: true, 0xCC: true,
+		0xD0: true, 0xD2: true, 0xD4: true, 0xD8: true, 0xDA: true, 0xDC: true,
+	}
+	run := func(code []byte) uint64 {
+		cpu.Bus.WriteBytes(code, 0xc000)
+		cpu.PC = 0xc000
+		cpu.SP = 0xd000
+		cpu.write_r16(HL, 0xc800)
+		cpu.Status = CPU_STATUS{}
+		cpu.Bus.Write(REG_IE, 0x00)
+		cpu.Bus.Write(REG_KEY1, 0x00)
+		before := cpu.Cycles
+		cpu.Step()
+		return cpu.Cycles - before
+	}
+
+	for op := 0; op < 0x100; op++ {
+		o := Operations[op]
+		if conditional[OPCODE(op)] || op == 0x76 || op == 0xCB || o.Mneumonic == "???" {
+			continue
+		}
+		if got := run([]byte{OPCODE(op), 0x00, 0x00}); got != uint64(o.T_States) {
+			t.Errorf("%02X %s took %d T-states, wanted %d", op, o.Mneumonic, got, o.T_States)
+		}
+	}
+	for op := 0; op < 0x100; op++ {
+		o := Prefixed_Operations[op]
+		if got := run([]byte{0xCB, OPCODE(op)}); got != uint64(o.T_States) {
+			t.Errorf("CB %02X %s took %d T-states, wanted %d", op, o.Mneumonic, got, o.T_States)
+		}
+	}
+}
+
+func TestIndependentInstances(t *testing.T) {
+	t.Parallel()
+	a := NewCPU()
+	b := NewCPU()
+
+	a.Bus.WriteBytes([]byte{0x3e, 0x42, 0xea, 0x00, 0xc0}, 0x0100) // ld A,n8, ld [a16],A
+	a.RunUntil(0x0105)
+	if a.Bus.Read(0xc000) != 0x42 || b.Bus.Read(0xc000) != 0x00 {
+		t.Fatalf("[c000] is %02x and %02x, wanted 42 and 00", a.Bus.Read(0xc000), b.Bus.Read(0xc000))
+	}
+	if b.A != 0x01 || b.Cycles != 0 {
+		t.Fatalf("second CPU has A %02x after %d T-states, wanted untouched", b.A, b.Cycles)
+	}
+}
+
+func TestIllegalOpcodeLockup(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	var reported *Fault
+	cpu.OnFault = func(f *Fault) { reported = f }
+	cpu.Bus.WriteBytes([]byte{0x00, 0xdd, 0x3c}, 0xc000) // nop, ???, inc A
+	cpu.PC = 0xc000
+	cpu.A = 0x00
+	cpu.Status.Interrupt_Enabled = true
+	cpu.Bus.Write(REG_IF, 0x00)
+	cpu.Bus.Write(REG_IE, INT_VBLANK)
+
+	err := cpu.Run()
+	fault, ok := err.(*Fault)
+	if !ok || reported != fault {
+		t.Fatalf("got error %v and reported fault %v, wanted the same *Fault", err, reported)
+	}
+	if fault.Opcode != 0xdd || fault.PC != 0xc001 || fault.Bank != 0 || fault.Cycle != 8 {
+		t.Fatalf("got fault %+v, wanted opcode dd at 00:c001 after 8 T-states", *fault)
+	}
+
+	// a locked CPU ignores interrupts and never executes again
+	cpu.Request_interrupt(INT_VBLANK)
+	if err := cpu.Step(); err != fault || cpu.PC != 0xc001 || cpu.A != 0x00 {
+		t.Fatalf("got error %v, PC %04x and A %02x after lockup", err, cpu.PC, cpu.A)
+	}
+
+	// until it is reset
+	cpu.Reset(MODEL_DMG)
+	if cpu.Status.Locked || cpu.Fault != nil {
+		t.Fatalf("locked is %v and fault is %v after reset", cpu.Status.Locked, cpu.Fault)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+
+	cpu.Bus.WriteBytes([]byte{
+		0x2a,             // ld A,[HL+]
+		0xc4, 0x00, 0xc1, // call NZ,a16
+	}, 0xc000)
+	cpu.Bus.WriteBytes([]byte{
+		0xcb, 0x46, // bit 0,[HL]
+		0xe0, 0x80, // ldh [a8],A
+	}, 0xc100)
+	cpu.PC = 0xc000
+	cpu.SP = 0xd000
+	cpu.write_r16(HL, 0xc800)
+	cpu.F = 0x00
+
+	cpu.Step()
+	e := cpu.ExecInfo
+	if e.Address != 0xc000 || e.Opcode != 0x2a || e.Mneumonic != "ld A,[HL+]" || e.AddressMode != MODE_INDIRECT || e.Effective != 0xc800 {
+		t.Fatalf("got %04x %04x %q mode %d at %04x", e.Address, e.Opcode, e.Mneumonic, e.AddressMode, e.Effective)
+	}
+	if e.Reads != R_H|R_L || e.Writes != R_A|R_H|R_L {
+		t.Fatalf("reads %v and writes %v, wanted H,L and A,H,L", e.Reads, e.Writes)
+	}
+
+	cpu.Step()
+	e = cpu.ExecInfo
+	if e.Mneumonic != "call NZ,a16" || e.Operands != [2]byte{0x00, 0xc1} || e.OperandCount != 2 || e.AddressMode != MODE_STACK || e.Effective != 0xcffe {
+		t.Fatalf("got %q operands % x mode %d at %04x", e.Mneumonic, e.Operands[:e.OperandCount], e.AddressMode, e.Effective)
+	}
+	if !e.Reads.Has(R_F|R_SP|R_PC) || !e.Writes.Has(R_SP|R_PC) {
+		t.Fatalf("reads %v and writes %v", e.Reads, e.Writes)
+	}
+
+	cpu.Step()
+	e = cpu.ExecInfo
+	if e.Address != 0xc100 || e.Opcode != 0xcb46 || e.Mneumonic != "bit 0,[HL]" || e.OperandCount != 0 || e.Effective != 0xc801 {
+		t.Fatalf("got %04x %04x %q operands % x at %04x", e.Address, e.Opcode, e.Mneumonic, e.Operands[:e.OperandCount], e.Effective)
+	}
+	if e.Reads != R_H|R_L || e.Writes != R_F {
+		t.Fatalf("reads %v and writes %v, wanted H,L and F", e.Reads, e.Writes)
+	}
+
+	cpu.Step()
+	e = cpu.ExecInfo
+	if e.AddressMode != MODE_HIGH || e.Effective != 0xff80 || e.Operands != [2]byte{0x80} || e.OperandCount != 1 || e.Reads != R_A {
+		t.Fatalf("got mode %d at %04x operands % x reads %v", e.AddressMode, e.Effective, e.Operands[:e.OperandCount], e.Reads)
+	}
+
+	// a dispatched interrupt replaces the previous instruction
+	cpu.Status.Interrupt_Enabled = true
+	cpu.Bus.Write(REG_IE, INT_TIMER)
+	cpu.Request_interrupt(INT_TIMER)
+	cpu.Step()
+	e = cpu.ExecInfo
+	if !e.Dispatch || e.Mneumonic != "int 50" || e.Address != 0xc104 || e.OperandCount != 0 || e.Effective != cpu.SP {
+		t.Fatalf("got %q at %04x operands % x writing %04x after dispatch", e.Mneumonic, e.Address, e.Operands[:e.OperandCount], e.Effective)
+	}
+}
+
+// Copies a 256 byte block, checksums it with a subroutine and loops forever
+var benchmarkProgram = []byte{
+	0x31, 0xfe, 0xdf, // 0150: ld SP,n16
+	0x21, 0x00, 0xc0, // 0153: ld HL,n16        ; loop
+	0x11, 0x00, 0xc1, // 0156: ld DE,n16
+	0x06, 0x00, //       0159: ld B,n8
+	0x2a,       //       015B: ld A,[HL+]       ; copy
+	0x12,       //       015C: ld [DE],A
+	0x13,       //       015D: inc DE
+	0x05,       //       015E: dec B
+	0x20, 0xfa, //       015F: jr NZ,e8 (copy)
+	0xcd, 0x70, 0x01, // 0161: call a16 (checksum)
+	0xc3, 0x53, 0x01, // 0164: jp a16 (loop)
+}
+
+var benchmarkChecksum = []byte{
+	0x21, 0x00, 0xc1, // 0170: ld HL,n16
+	0x0e, 0x00, //       0173: ld C,n8
+	0xaf,       //       0175: xor A
+	0x86,       //       0176: add [HL]         ; sum
+	0xcb, 0x07, //       0177: rlc A
+	0xcb, 0x7f, //       0179: bit 7,A
+	0x2c,       //       017B: inc L
+	0x0d,       //       017C: dec C
+	0x20, 0xf7, //       017D: jr NZ,e8 (sum)
+	0xe0, 0x80, //       017F: ldh [a8],A
+	0xc9, //             0181: ret
+}
+
+func BenchmarkCPU(b *testing.B) {
+	cpu := NewCPU()
+	cpu.Bus.WriteBytes(benchmarkProgram, 0x0150)
+	cpu.Bus.WriteBytes(benchmarkChecksum, 0x0170)
+	cpu.PC = 0x0150
+
+	b.ResetTimer()
+	start := cpu.Cycles
+	for i := 0; i < b.N; i++ {
+		if err := cpu.Step(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	elapsed := b.Elapsed().Seconds()
+	if elapsed > 0 {
+		b.ReportMetric(float64(cpu.Cycles-start)/elapsed/1e6, "MHz")
+	}
+}
+
+func TestTimer(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+	tick := func(t_states int) {
+		for ; t_states > 0; t_states -= 4 {
+			cpu.Timer.Tick(4)
+		}
+	}
+	if div := cpu.Bus.Read(REG_DIV); div != 0xab {
+		t.Errorf("DIV is %02x after boot, wanted ab", div)
+	}
+	cpu.Bus.Write(REG_DIV, 0x42) // any write clears it
+	tick(252)
+	if div := cpu.Bus.Read(REG_DIV); div != 0x00 {
+		t.Errorf("DIV is %02x after 252 T-states, wanted 00", div)
+	}
+	tick(4)
+	if div := cpu.Bus.Read(REG_DIV); div != 0x01 {
+		t.Errorf("DIV is %02x after 256 T-states, wanted 01", div)
+	}
+
+	// 262144 Hz, TIMA counts every 16 T-states and reloads from TMA an M-cycle after overflowing
+	cpu.Bus.Write(REG_IF, 0x00)
+	cpu.Bus.Write(REG_DIV, 0x00)
+	cpu.Bus.Write(REG_TMA, 0x10)
+	cpu.Bus.Write(REG_TIMA, 0xfe)
+	cpu.Bus.Write(REG_TAC, 0x05)
+	tick(16)
+	if tima := cpu.Bus.Read(REG_TIMA); tima != 0xff {
+		t.Errorf("TIMA is %02x after 16 T-states, wanted ff", tima)
+	}
+	tick(16)
+	if tima, irq := cpu.Bus.Read(REG_TIMA), cpu.Bus.Read(REG_IF); tima != 0x00 || irq&INT_TIMER != 0 {
+		t.Errorf("TIMA is %02x and IF %02x on overflow, wanted 00 without the interrupt", tima, irq)
+	}
+	tick(4)
+	if tima, irq := cpu.Bus.Read(REG_TIMA), cpu.Bus.Read(REG_IF); tima != 0x10 || irq&INT_TIMER == 0 {
+		t.Errorf("TIMA is %02x and IF %02x after the reload, wanted 10 with the interrupt", tima, irq)
+	}
+	if tac := cpu.Bus.Read(REG_TAC); tac != 0xfd {
+		t.Errorf("TAC reads %02x, wanted fd", tac)
+	}
+
+	// clearing DIV while the selected bit is set is a falling edge
+	cpu.Bus.Write(REG_DIV, 0x00)
+	tick(8)
+	cpu.Bus.Write(REG_DIV, 0x00)
+	if tima := cpu.Bus.Read(REG_TIMA); tima != 0x11 {
+		t.Errorf("TIMA is %02x after resetting DIV, wanted 11", tima)
+	}
+
+	// writing TIMA in the cycle after an overflow cancels the reload and the interrupt
+	cpu.Bus.Write(REG_IF, 0x00)
+	cpu.Bus.Write(REG_DIV, 0x00)
+	cpu.Bus.Write(REG_TIMA, 0xff)
+	tick(16)
+	cpu.Bus.Write(REG_TIMA, 0x80)
+	tick(4)
+	if tima, irq := cpu.Bus.Read(REG_TIMA), cpu.Bus.Read(REG_IF); tima != 0x80 || irq&INT_TIMER != 0 {
+		t.Errorf("TIMA is %02x and IF %02x after a cancelled reload, wanted 80 without the interrupt", tima, irq)
+	}
+}
+
+func TestModels(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		model                  MODEL
+		a, f, b, c, d, e, h, l uint8
+		div, sc                uint8
+	}{
+		{MODEL_DMG0, 0x01, 0x00, 0xff, 0x13, 0x00, 0xc1, 0x84, 0x03, 0x18, 0x7e},
+		{MODEL_DMG, 0x01, 0xb0, 0x00, 0x13, 0x00, 0xd8, 0x01, 0x4d, 0xab, 0x7e},
+		{MODEL_MGB, 0xff, 0xb0, 0x00, 0x13, 0x00, 0xd8, 0x01, 0x4d, 0xab, 0x7e},
+		{MODEL_SGB, 0x01, 0x00, 0x00, 0x14, 0x00, 0x00, 0xc0, 0x60, 0x00, 0x7e},
+		{MODEL_SGB2, 0xff, 0x00, 0x00, 0x14, 0x00, 0x00, 0xc0, 0x60, 0x00, 0x7e},
+		{MODEL_CGB, 0x11, 0x80, 0x00, 0x00, 0xff, 0x56, 0x00, 0x0d, 0x00, 0x7f},
+		{MODEL_AGB, 0x11, 0x00, 0x01, 0x00, 0xff, 0x56, 0x00, 0x0d, 0x00, 0x7f},
+	} {
+		cpu := &CPU{Bus: NewBus()}
+		cpu.Bus.Write(0x014d, 0xe7) // non zero header checksum
+		cpu.Reset(tc.model)
+		got := [...]uint8{cpu.A, cpu.F, cpu.B, cpu.C, cpu.D, cpu.E, cpu.H, cpu.L, cpu.Bus.Read(REG_DIV), cpu.Bus.Read(REG_SC)}
+		want := [...]uint8{tc.a, tc.f, tc.b, tc.c, tc.d, tc.e, tc.h, tc.l, tc.div, tc.sc}
+		if got != want || cpu.SP != 0xfffe || cpu.PC != 0x0100 || cpu.Model != tc.model {
+			t.Errorf("%v: got AF..L, DIV, SC % x, SP %04x, PC %04x, wanted % x, fffe, 0100", tc.model, got, cpu.SP, cpu.PC, want)
+		}
+		if m, err := ParseModel(strings.ToLower(tc.model.String())); err != nil || m != tc.model {
+			t.Errorf("ParseModel(%q) gave %v, %v", tc.model, m, err)
+		}
+	}
+
+	// H and C are only set by the DMG boot ROM when the header checksum is not zero
+	cpu := NewCPU()
+	if cpu.F != 0x80 {
+		t.Errorf("DMG with zero header checksum has F %02x, wanted 80", cpu.F)
+	}
+
+	// only CGB hardware switches speed on STOP
+	cpu.Bus.WriteBytes([]byte{0x10, 0x00}, 0xc000)
+	cpu.PC = 0xc000
+	cpu.Bus.Write(REG_KEY1, 0x01)
+	cpu.Step()
+	if !cpu.Status.Stopped || cpu.Bus.Read(REG_KEY1) != 0x01 {
+		t.Errorf("DMG stopped is %v and KEY1 is %02x, wanted true and 01", cpu.Status.Stopped, cpu.Bus.Read(REG_KEY1))
+	}
+}
+
+func TestBootROM(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPU()
+	cpu.Bus.WriteBytes([]byte{0x31, 0x32, 0x33}, 0x0000) // cartridge bytes under the overlay
+	cpu.Bus.WriteBytes([]byte{0x00, 0x3c}, 0x0100)       // nop, inc A
+
+	if _, err := LoadBootROM(cpu, make([]byte, 0x200)); err == nil {
+		t.Fatalf("512 byte boot ROM was accepted")
+	}
+	if _, err := LoadBootROM(cpu, make([]byte, BOOT_ROM_SIZE_CGB)); err == nil {
+		t.Fatalf("CGB boot ROM was accepted on a DMG")
+	}
+
+	// the DMG boot ROM ends by writing 1 to 0xFF50 at 0x00FC, falling through to 0x0100
+	image := make([]byte, BOOT_ROM_SIZE_DMG)
+	copy(image[0xfc:], []byte{0x3e, 0x01, 0xe0, 0x50}) // ld A,0x01, ldh [a8],A
+	boot, err := LoadBootROM(cpu, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu.PC != 0x0000 || cpu.Bus.Read(0x0000) != 0x00 || !boot.Mapped {
+		t.Fatalf("PC is %04x and [0000] is %02x, wanted the boot ROM at 0000", cpu.PC, cpu.Bus.Read(0x0000))
+	}
+
+	cpu.RunUntil(0x0100)
+	cpu.Step()
+	if boot.Mapped || cpu.Bus.Read(0x0000) != 0x31 || cpu.Bus.Read(0x0002) != 0x33 || cpu.PC != 0x0101 {
+		t.Fatalf("[0000] is %02x and PC is %04x after the write to FF50, wanted the cartridge at 0000", cpu.Bus.Read(0x0000), cpu.PC)
+	}
+
+	// the CGB boot ROM leaves the cartridge header at 0x0100-0x01FF visible
+	cgb := NewCPUModel(MODEL_CGB)
+	cgb.Bus.Write(0x0150, 0x55)
+	image = make([]byte, BOOT_ROM_SIZE_CGB)
+	image[0x0150], image[0x0200], image[0x08ff] = 0xaa, 0xbb, 0xcc
+	boot, err = LoadBootROM(cgb, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cgb.Bus.Read(0x0150) != 0x55 || cgb.Bus.Read(0x0200) != 0xbb || cgb.Bus.Read(0x08ff) != 0xcc {
+		t.Fatalf("CGB overlay has [0150] %02x, [0200] %02x, [08ff] %02x", cgb.Bus.Read(0x0150), cgb.Bus.Read(0x0200), cgb.Bus.Read(0x08ff))
+	}
+	boot.Unmap()
+	if cgb.Bus.Read(0x0200) != 0x00 || cgb.Bus.Read(0x0150) != 0x55 {
+		t.Fatalf("[0200] is %02x after unmapping, wanted the cartridge", cgb.Bus.Read(0x0200))
+	}
+}
+
+func TestBusRegions(t *testing.T) {
+	t.Parallel()
+	for addr, want := range map[uint16]REGION{
+		0x0000: REGION_ROM0, 0x3fff: REGION_ROM0, 0x4000: REGION_ROMX, 0x7fff: REGION_ROMX,
+		0x8000: REGION_VRAM, 0x9fff: REGION_VRAM, 0xa000: REGION_ERAM, 0xbfff: REGION_ERAM,
+		0xc000: REGION_WRAM, 0xdfff: REGION_WRAM, 0xe000: REGION_ECHO, 0xfdff: REGION_ECHO,
+		0xfe00: REGION_OAM, 0xfe9f: REGION_OAM, 0xfea0: REGION_UNUSABLE, 0xfeff: REGION_UNUSABLE,
+		0xff00: REGION_IO, 0xff7f: REGION_IO, 0xff80: REGION_HRAM, 0xfffe: REGION_HRAM, 0xffff: REGION_IE,
+	} {
+		if got := Decode(addr); got != want {
+			t.Errorf("%04x decoded to %v, wanted %v", addr, got, want)
+		}
+	}
+
+	bus := NewBus()
+	bus.Write(0xe123, 0x42) // echo RAM mirrors work RAM both ways
+	bus.Write(0xd000, 0x24)
+	if bus.Read(0xc123) != 0x42 || bus.Read(0xf000) != 0x24 {
+		t.Errorf("[c123] is %02x and [f000] is %02x, wanted 42 and 24", bus.Read(0xc123), bus.Read(0xf000))
+	}
+	bus.Write(0xfea0, 0xff)
+	if bus.Read(0xfea0) != 0x00 {
+		t.Errorf("unusable area reads %02x, wanted 00", bus.Read(0xfea0))
+	}
+
+	// devices replace a whole region or a single I/O register
+	bus.Map(REGION_ROMX, Unmapped(0xaa))
+	bus.MapIO(0xff42, Unmapped(0xbb))
+	bus.Write(0x4000, 0x00)
+	bus.Write(0xff43, 0xcc)
+	if bus.Read(0x4000) != 0xaa || bus.Read(0x7fff) != 0xaa || bus.Read(0xff42) != 0xbb || bus.Read(0xff43) != 0xcc {
+		t.Errorf("mapped devices read %02x %02x %02x %02x", bus.Read(0x4000), bus.Read(0x7fff), bus.Read(0xff42), bus.Read(0xff43))
+	}
+}
+
+// Infrared link recording what is emitted on it
+type emitLog struct {
+	emits []bool
+}
+
+func (l *emitLog) Emit(on bool) {
+	l.emits = append(l.emits, on)
+}
+
+func (l *emitLog) Receive() bool {
+	return false
+}
+
+func TestInfraredPort(t *testing.T) {
+	t.Parallel()
+	cpu := NewCPUModel(MODEL_CGB)
+	p := cpu.Infrared
+	if p == nil {
+		t.Fatal("no infrared port on a CGB")
+	}
+	if got := cpu.Bus.Read(REG_RP); got != 0x3e {
+		t.Errorf("RP reads %02x after boot, wanted 3e", got)
+	}
+	cpu.Bus.Write(REG_RP, 0x01) // LED on, reading disabled
+	if got := cpu.Bus.Read(REG_RP); got != 0x3f {
+		t.Errorf("RP reads %02x with reading disabled, wanted 3f", got)
+	}
+	cpu.Bus.Write(REG_RP, 0xc1) // the loopback sees its own LED
+	if got := cpu.Bus.Read(REG_RP); got != 0xfd {
+		t.Errorf("RP reads %02x looped back, wanted fd", got)
+	}
+	p.Link = &Loopback{}
+	if got := cpu.Bus.Read(REG_RP); got != 0xff {
+		t.Errorf("RP reads %02x with a dark link, wanted ff", got)
+	}
+
+	// the port is kept across CGB resets, which turn the LED off without flashing it
+	link := &emitLog{}
+	p.Link = link
+	cpu.Bus.Write(REG_RP, 0x01)
+	link.emits = nil
+	cpu.Reset(MODEL_AGB)
+	cpu.Reset(MODEL_AGB)
+	if cpu.Infrared != p || cpu.Bus.Read(REG_RP) != 0x3e {
+		t.Errorf("AGB reset replaced the port or left RP at %02x", cpu.Bus.Read(REG_RP))
+	}
+	if len(link.emits) != 1 || link.emits[0] {
+		t.Errorf("resets emitted %v, wanted the LED turned off once", link.emits)
+	}
+
+	// and removed on DMG models
+	cpu.Reset(MODEL_DMG)
+	if cpu.Infrared != nil || cpu.Bus.Read(REG_RP) != 0xff {
+		t.Errorf("DMG reset kept the port or left RP at %02x", cpu.Bus.Read(REG_RP))
+	}
+}
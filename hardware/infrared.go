@@ -0,0 +1,85 @@
+package hardware
+
+const REG_RP = 0xFF56 // CGB infrared communications port
+
+// Infrared is the host side of an infrared link, Emit lights the LED and Receive senses light
+type Infrared interface {
+	Emit(on bool)
+	Receive() bool
+}
+
+// Loopback is the default link of a single port, it sees only its own LED as if facing a mirror
+type Loopback struct {
+	on bool
+}
+
+func (l *Loopback) Emit(on bool) {
+	l.on = on
+}
+
+func (l *Loopback) Receive() bool {
+	return l.on
+}
+
+// SharedLink is a light path between ports, each Connect has its own LED and sees any lit one
+type SharedLink struct {
+	leds []bool
+}
+
+type shared_port struct {
+	link *SharedLink
+	led  int
+}
+
+func (l *SharedLink) Connect() Infrared {
+	l.leds = append(l.leds, false)
+	return &shared_port{l, len(l.leds) - 1}
+}
+
+func (p *shared_port) Emit(on bool) {
+	p.link.leds[p.led] = on
+}
+
+func (p *shared_port) Receive() bool {
+	for _, on := range p.link.leds {
+		if on {
+			return true
+		}
+	}
+	return false
+}
+
+type InfraredPort struct {
+	rp byte // LED and read enable bits as last written
+
+	Link Infrared
+}
+
+func NewInfraredPort(cpu *CPU) *InfraredPort {
+	// Create the CGB infrared port handling RP on the CPU's bus, looped back until Link is replaced
+	p := &InfraredPort{Link: &Loopback{}}
+	cpu.Bus.MapIO(REG_RP, p)
+	return p
+}
+
+func (p *InfraredPort) reset() {
+	// Turn the LED off and disable reading without writing RP
+	if p.rp&0x01 != 0 {
+		p.Link.Emit(false)
+	}
+	p.rp = 0
+}
+
+func (p *InfraredPort) Read(addr uint16) byte {
+	// Bit 1 is 0 while light is received, it only reads when bits 6 and 7 enable it
+	b := p.rp | 0x3e
+	if p.rp&0xc0 == 0xc0 && p.Link.Receive() {
+		b &^= 0x02
+	}
+	return b
+}
+
+func (p *InfraredPort) Write(addr uint16, b byte) {
+	p.rp = b & 0xc1
+	p.Link.Emit(b&0x01 != 0)
+}
@@ -5,6 +5,11 @@ func (c *CPU) NOP() {
 	c.PC++
 }
 
+func (c *CPU) ILLEGAL() {
+	// 0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD lock up the CPU
+	c.lock(OPCODE(c.ExecInfo.Opcode))
+}
+
 func (c *CPU) LD_BC_n16() {
 	// 0x01 Load word to BC register
 	w := c.read_word()
@@ -15,7 +20,7 @@ func (c *CPU) LD_BC_n16() {
 func (c *CPU) LD_ADDR_BC_A() {
 	// 0x02 Store accumulator to location [BC]
 	addr := c.read_r16(BC)
-	c.Bus.Write(addr, c.A)
+	c.write_mem(addr, c.A)
 	c.PC++
 }
 
@@ -23,6 +28,7 @@ func (c *CPU) INC_BC() {
 	// 0x03 Increment BC register without carry
 	bc := c.read_r16(BC) + 1
 	c.write_r16(BC, bc)
+	c.tick()
 	c.PC++
 }
 
@@ -57,7 +63,7 @@ func (c *CPU) RLCA() {
 	carry := c.A >> 7
 	c.A = c.A<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	c.PC++
 }
@@ -67,8 +73,8 @@ func (c *CPU) LD_ADDR_a16_SP() {
 	addr := c.read_word()
 	s := uint8(c.SP >> 8)
 	p := uint8(c.SP)
-	c.Bus.Write(addr, p)
-	c.Bus.Write(addr+1, s)
+	c.write_mem(addr, p)
+	c.write_mem(addr+1, s)
 	c.PC++
 }
 
@@ -80,13 +86,14 @@ func (c *CPU) ADD_HL_BC() {
 	res, flags := c.add16(hl, bc)
 	c.F = (flags & ^FLAG_Z) | (c.F & FLAG_Z) // preserve zero flag
 	c.write_r16(HL, res)
+	c.tick()
 	c.PC++
 }
 
 func (c *CPU) LD_A_ADDR_BC() {
 	// 0x0A Load contents of [BC] to A
 	addr := c.read_r16(BC)
-	c.A = c.Bus.Read(addr)
+	c.A = c.read_mem(addr)
 	c.PC++
 }
 
@@ -94,6 +101,7 @@ func (c *CPU) DEC_BC() {
 	// 0x0B Decrement BC register
 	bc := c.read_r16(BC) - 1
 	c.write_r16(BC, bc)
+	c.tick()
 	c.PC++
 }
 
@@ -129,14 +137,22 @@ func (c *CPU) RRCA() {
 	carry := c.A & 0x01
 	c.A = c.A >> 1
 	c.A |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	c.PC++
 }
 
 func (c *CPU) STOP() {
-	// 0x10 Enter CPU low power mode
-	//TODO
-	c.PC++
+	// 0x10 Enter CPU low power mode, or switch speed if armed through KEY1
+	c.PC += 2
+	c.Bus.Write(REG_DIV, 0)
+
+	if c.Speed != nil && c.Speed.armed {
+		c.Speed.double = !c.Speed.double
+		c.Speed.armed = false
+		return
+	}
+	c.Status.Stopped = true
 }
 
 func (c *CPU) LD_DE_n16() {
@@ -149,7 +165,7 @@ func (c *CPU) LD_DE_n16() {
 func (c *CPU) LD_ADDR_DE_A() {
 	// 0x12 Store A into address [DE]
 	addr := c.read_r16(DE)
-	c.Bus.Write(addr, c.A)
+	c.write_mem(addr, c.A)
 	c.PC++
 }
 
@@ -157,6 +173,7 @@ func (c *CPU) INC_DE() {
 	// 0x13 Increment DE register
 	de := c.read_r16(DE) + 1
 	c.write_r16(DE, de)
+	c.tick()
 	c.PC++
 }
 
@@ -192,7 +209,7 @@ func (c *CPU) RLA() {
 	carry := (c.F & FLAG_C) >> 4
 	c.A = (c.A << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	c.PC++
 }
@@ -200,8 +217,9 @@ func (c *CPU) RLA() {
 func (c *CPU) JR_e8() {
 	// 0x18 Jump relative by [-128,127] offset
 	e := c.read_byte()
-	addr := int16(c.PC) + int16(int8(e))
+	addr := int16(c.PC+1) + int16(int8(e))
 	c.PC = uint16(addr)
+	c.tick()
 }
 
 func (c *CPU) ADD_HL_DE() {
@@ -212,13 +230,14 @@ func (c *CPU) ADD_HL_DE() {
 	res, flags := c.add16(hl, de)
 	c.F = (flags & ^FLAG_Z) | (c.F & FLAG_Z) // preserve zero flag
 	c.write_r16(HL, res)
+	c.tick()
 	c.PC++
 }
 
 func (c *CPU) LD_A_ADDR_DE() {
 	// 0x1A Load A with contents of [DE]
 	addr := c.read_r16(DE)
-	c.A = c.Bus.Read(addr)
+	c.A = c.read_mem(addr)
 	c.PC++
 }
 
@@ -226,6 +245,7 @@ func (c *CPU) DEC_DE() {
 	// 0x1B Decrement DE by one
 	de := c.read_r16(DE) - 1
 	c.write_r16(DE, de)
+	c.tick()
 	c.PC++
 }
 
@@ -261,7 +281,7 @@ func (c *CPU) RRA() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.A = (c.A >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0)
 	c.PC++
 }
@@ -272,8 +292,9 @@ func (c *CPU) JR_NZ_e8() {
 	z := (c.F) & FLAG_Z
 
 	if z == 0 {
-		des := int16(c.PC) + int16(int8(e))
+		des := int16(c.PC+1) + int16(int8(e))
 		c.PC = uint16(des)
+		c.tick()
 		return
 	}
 	c.PC++
@@ -289,7 +310,7 @@ func (c *CPU) LD_HL_n16() {
 func (c *CPU) LDI_ADDR_HL_A() {
 	// 0x22 Load A to address [HL] and increment HL
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.A)
+	c.write_mem(hl, c.A)
 	c.write_r16(HL, hl+1)
 	c.PC++
 }
@@ -298,6 +319,7 @@ func (c *CPU) INC_HL() {
 	// 0x23 Increment HL
 	hl := c.read_r16(HL) + 1
 	c.write_r16(HL, hl)
+	c.tick()
 	c.PC++
 }
 
@@ -328,26 +350,30 @@ func (c *CPU) LD_H_n8() {
 }
 
 func (c *CPU) DAA() {
-	// 0x27 Adjust Accumulator for BCD, if A[0:3] > 9 then A <- A + 0x06, if A[4:8] > 9, then A <- A + 0x60
-	a := uint16(c.A)
-	h_flag := (c.F & FLAG_H)
-	c_flag := (c.F & FLAG_C)
-
-	lo_nibble := a & 0x0f
-	hi_nibble := (a & 0xf0) >> 4
-
-	if lo_nibble > 9 || h_flag != 0 {
-		a += 0x06
-	}
-	if hi_nibble > 9 || c_flag != 0 {
-		a += 0x60
+	// 0x27 Adjust Accumulator for BCD after an addition or subtraction, using the N, H and C flags
+	a := c.A
+	flags := c.F & (FLAG_N | FLAG_C) // Preserve N and C -> 0N0C
+
+	if c.F&FLAG_N == 0 {
+		if c.F&FLAG_C != 0 || a > 0x99 {
+			a += 0x60
+			flags |= FLAG_C
+		}
+		if c.F&FLAG_H != 0 || a&0x0f > 9 {
+			a += 0x06
+		}
+	} else {
+		if c.F&FLAG_C != 0 {
+			a -= 0x60
+		}
+		if c.F&FLAG_H != 0 {
+			a -= 0x06
+		}
 	}
-	c.A = uint8(a)
+	c.A = a
 
-	flags := (uint8(a>>8) << 4) // Get C -> 000C
-	flags |= (c.F & FLAG_N)     // Preserve N -> 0-0C
 	if c.A == 0 {
-		flags |= FLAG_Z // Get Z -> Z-0C
+		flags |= FLAG_Z // Get Z -> ZN0C
 	}
 	c.F = flags
 	c.PC++
@@ -359,8 +385,9 @@ func (c *CPU) JR_Z_e8() {
 	z := (c.F) & FLAG_Z
 
 	if z != 0 {
-		dest := int16(c.PC) + int16(int8(e))
+		dest := int16(c.PC+1) + int16(int8(e))
 		c.PC = uint16(dest)
+		c.tick()
 		return
 	}
 	c.PC++
@@ -373,13 +400,14 @@ func (c *CPU) ADD_HL_HL() {
 	res, flags := c.add16(hl, hl)
 	c.F = (flags & ^FLAG_Z) | (c.F & FLAG_Z) // preserve zero flag
 	c.write_r16(HL, res)
+	c.tick()
 	c.PC++
 }
 
 func (c *CPU) LDI_A_ADDR_HL() {
 	// 0x2A Load [HL] to A and increment HL
 	hl := c.read_r16(HL)
-	c.A = c.Bus.Read(hl)
+	c.A = c.read_mem(hl)
 	c.write_r16(HL, hl+1)
 	c.PC++
 }
@@ -388,6 +416,7 @@ func (c *CPU) DEC_HL() {
 	// 0x2B Decrement HL by one
 	hl := c.read_r16(HL) - 1
 	c.write_r16(HL, hl)
+	c.tick()
 	c.PC++
 }
 
@@ -430,8 +459,9 @@ func (c *CPU) JR_NC_e8() {
 	_c := (c.F) & FLAG_C
 
 	if _c == 0 {
-		dest := int16(c.PC) + int16(int8(e))
+		dest := int16(c.PC+1) + int16(int8(e))
 		c.PC = uint16(dest)
+		c.tick()
 		return
 	}
 	c.PC++
@@ -446,7 +476,7 @@ func (c *CPU) LD_SP_n16() {
 func (c *CPU) LDD_ADDR_HL_A() {
 	// 0x32 Store A to [HL] and decrement HL
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.A)
+	c.write_mem(hl, c.A)
 	c.write_r16(HL, hl-1)
 	c.PC++
 }
@@ -454,16 +484,17 @@ func (c *CPU) LDD_ADDR_HL_A() {
 func (c *CPU) INC_SP() {
 	// 0x33 Increment Stack Pointer
 	c.SP++
+	c.tick()
 	c.PC++
 }
 
 func (c *CPU) INC_ADDR_HL() {
 	// 0x34 Increment value at address [HL]
 	hl := c.read_r16(HL)
-	res, flags := c.add8(c.Bus.Read(hl), 0, 1)
+	res, flags := c.add8(c.read_mem(hl), 0, 1)
 	flags = (flags & ^FLAG_C) | (c.F & FLAG_C)
 
-	c.Bus.Write(hl, res)
+	c.write_mem(hl, res)
 	c.F = flags
 	c.PC++
 }
@@ -471,10 +502,10 @@ func (c *CPU) INC_ADDR_HL() {
 func (c *CPU) DEC_ADDR_HL() {
 	// 0x35 Decrement value at address [HL]
 	hl := c.read_r16(HL)
-	res, flags := c.sub8(c.Bus.Read(hl), 0, 1)
+	res, flags := c.sub8(c.read_mem(hl), 0, 1)
 	flags = (flags & ^FLAG_C) | (c.F & FLAG_C)
 
-	c.Bus.Write(hl, res)
+	c.write_mem(hl, res)
 	c.F = flags
 	c.PC++
 }
@@ -483,7 +514,7 @@ func (c *CPU) LD_ADDR_HL_n8() {
 	// 0x36 Load value to address [HL]
 	hl := c.read_r16(HL)
 	n := c.read_byte()
-	c.Bus.Write(hl, n)
+	c.write_mem(hl, n)
 	c.PC++
 }
 
@@ -500,8 +531,9 @@ func (c *CPU) JR_C_e8() {
 	_c := (c.F) & FLAG_C
 
 	if _c != 0 {
-		dest := int16(c.PC) + int16(int8(e))
+		dest := int16(c.PC+1) + int16(int8(e))
 		c.PC = uint16(dest)
+		c.tick()
 		return
 	}
 	c.PC++
@@ -514,13 +546,14 @@ func (c *CPU) ADD_HL_SP() {
 	res, flags := c.add16(hl, c.SP)
 	c.F = (flags & ^FLAG_Z) | (c.F & FLAG_Z) // preserve zero flag
 	c.write_r16(HL, res)
+	c.tick()
 	c.PC++
 }
 
 func (c *CPU) LDD_A_ADDR_HL() {
 	// 0x3A Load [HL] to A and decrement HL
 	hl := c.read_r16(HL)
-	c.A = c.Bus.Read(hl)
+	c.A = c.read_mem(hl)
 	c.write_r16(HL, hl-1)
 	c.PC++
 }
@@ -528,6 +561,7 @@ func (c *CPU) LDD_A_ADDR_HL() {
 func (c *CPU) DEC_SP() {
 	// 0x3B Decrement stack pointer
 	c.SP--
+	c.tick()
 	c.PC++
 }
 
@@ -604,7 +638,7 @@ func (c *CPU) LD_B_L() {
 func (c *CPU) LD_B_ADDR_HL() {
 	// 0x46 Load B with value at address [HL]
 	hl := c.read_r16(HL)
-	c.B = c.Bus.Read(hl)
+	c.B = c.read_mem(hl)
 	c.PC++
 }
 
@@ -653,7 +687,7 @@ func (c *CPU) LD_C_L() {
 func (c *CPU) LD_C_ADDR_HL() {
 	// 0x4E Load C with value at address [HL]
 	hl := c.read_r16(HL)
-	c.C = c.Bus.Read(hl)
+	c.C = c.read_mem(hl)
 	c.PC++
 }
 
@@ -702,7 +736,7 @@ func (c *CPU) LD_D_L() {
 func (c *CPU) LD_D_ADDR_HL() {
 	// 0x56 Load D with value at address [HL]
 	hl := c.read_r16(HL)
-	c.D = c.Bus.Read(hl)
+	c.D = c.read_mem(hl)
 	c.PC++
 }
 
@@ -751,7 +785,7 @@ func (c *CPU) LD_E_L() {
 func (c *CPU) LD_E_ADDR_HL() {
 	// 0x5E Load C with value at address [HL]
 	hl := c.read_r16(HL)
-	c.E = c.Bus.Read(hl)
+	c.E = c.read_mem(hl)
 	c.PC++
 }
 
@@ -800,7 +834,7 @@ func (c *CPU) LD_H_L() {
 func (c *CPU) LD_H_ADDR_HL() {
 	// 0x66 Load H with value at address [HL]
 	hl := c.read_r16(HL)
-	c.H = c.Bus.Read(hl)
+	c.H = c.read_mem(hl)
 	c.PC++
 }
 
@@ -849,7 +883,7 @@ func (c *CPU) LD_L_L() {
 func (c *CPU) LD_L_ADDR_HL() {
 	// 0x6E Load L with value at address [HL]
 	hl := c.read_r16(HL)
-	c.L = c.Bus.Read(hl)
+	c.L = c.read_mem(hl)
 	c.PC++
 }
 
@@ -862,55 +896,59 @@ func (c *CPU) LD_L_A() {
 func (c *CPU) LD_ADDR_HL_B() {
 	// 0x70 Load address [HL] with B register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.B)
+	c.write_mem(hl, c.B)
 	c.PC++
 }
 
 func (c *CPU) LD_ADDR_HL_C() {
 	// 0x71 Load address [HL] with C register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.C)
+	c.write_mem(hl, c.C)
 	c.PC++
 }
 
 func (c *CPU) LD_ADDR_HL_D() {
 	// 0x72 Load address [HL] with D register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.D)
+	c.write_mem(hl, c.D)
 	c.PC++
 }
 
 func (c *CPU) LD_ADDR_HL_E() {
 	// 0x73 Load address [HL] with E register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.E)
+	c.write_mem(hl, c.E)
 	c.PC++
 }
 
 func (c *CPU) LD_ADDR_HL_H() {
 	// 0x74 Load address [HL] with H register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.H)
+	c.write_mem(hl, c.H)
 	c.PC++
 }
 
 func (c *CPU) LD_ADDR_HL_L() {
 	// 0x75 Load address [HL] with L register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.L)
+	c.write_mem(hl, c.L)
 	c.PC++
 }
 
 func (c *CPU) HALT() {
-	// 0x76 Load address [HL] with value at address [HL]
-	//TODO behavior depends on interrupts
+	// 0x76 Suspend instruction fetch until an interrupt is pending
 	c.PC++
+	if !c.Status.Interrupt_Enabled && c.pending_interrupts() != 0 {
+		c.Status.Halt_Bug = true // HALT is skipped and the next byte is read twice
+		return
+	}
+	c.Status.Halted = true
 }
 
 func (c *CPU) LD_ADDR_HL_A() {
 	// 0x77 Load address [HL] with A register
 	hl := c.read_r16(HL)
-	c.Bus.Write(hl, c.A)
+	c.write_mem(hl, c.A)
 	c.PC++
 }
 
@@ -953,7 +991,7 @@ func (c *CPU) LD_A_L() {
 func (c *CPU) LD_A_ADDR_HL() {
 	// 0x7E Load A with value at address [HL]
 	hl := c.read_r16(HL)
-	c.A = c.Bus.Read(hl)
+	c.A = c.read_mem(hl)
 	c.PC++
 }
 
@@ -1020,7 +1058,7 @@ func (c *CPU) ADD_L() {
 func (c *CPU) ADD_ADDR_HL() {
 	// 0x86 Add [HL]
 	hl := c.read_r16(HL)
-	res, flags := c.add8(c.A, c.Bus.Read(hl), 0)
+	res, flags := c.add8(c.A, c.read_mem(hl), 0)
 
 	c.A = res
 	c.F = flags
@@ -1100,7 +1138,7 @@ func (c *CPU) ADC_ADDR_HL() {
 	// 0x8E Add [HL] With carry
 	carry := c.F & FLAG_C
 	hl := c.read_r16(HL)
-	res, flags := c.add8(c.A, c.Bus.Read(hl), carry)
+	res, flags := c.add8(c.A, c.read_mem(hl), carry)
 
 	c.A = res
 	c.F = flags
@@ -1174,7 +1212,7 @@ func (c *CPU) SUB_L() {
 func (c *CPU) SUB_ADDR_HL() {
 	// 0x96 Subtract [HL]
 	hl := c.read_r16(HL)
-	res, flags := c.sub8(c.A, c.Bus.Read(hl), 0)
+	res, flags := c.sub8(c.A, c.read_mem(hl), 0)
 
 	c.A = res
 	c.F = flags
@@ -1254,7 +1292,7 @@ func (c *CPU) SBC_ADDR_HL() {
 	// 0x9E Subtract [HL] with carry
 	carry := c.F & FLAG_C
 	hl := c.read_r16(HL)
-	res, flags := c.sub8(c.A, c.Bus.Read(hl), carry)
+	res, flags := c.sub8(c.A, c.read_mem(hl), carry)
 
 	c.A = res
 	c.F = flags
@@ -1340,7 +1378,7 @@ func (c *CPU) AND_L() {
 func (c *CPU) AND_ADDR_HL() {
 	// 0xA6 And [HL]
 	hl := c.read_r16(HL)
-	c.A &= c.Bus.Read(hl)
+	c.A &= c.read_mem(hl)
 	c.Flag_set(FLAG_H)
 	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_C)
 	if c.A == 0 {
@@ -1429,7 +1467,7 @@ func (c *CPU) XOR_L() {
 func (c *CPU) XOR_ADDR_HL() {
 	// 0xAE Xor [HL]
 	hl := c.read_r16(HL)
-	c.A ^= c.Bus.Read(hl)
+	c.A ^= c.read_mem(hl)
 
 	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	if c.A == 0 {
@@ -1518,7 +1556,7 @@ func (c *CPU) OR_L() {
 func (c *CPU) OR_ADDR_HL() {
 	// 0xB6 Or [HL]
 	hl := c.read_r16(HL)
-	c.A |= c.Bus.Read(hl)
+	c.A |= c.read_mem(hl)
 
 	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	if c.A == 0 {
@@ -1583,7 +1621,7 @@ func (c *CPU) CP_L() {
 func (c *CPU) CP_ADDR_HL() {
 	// 0xBE Compare [HL]
 	hl := c.read_r16(HL)
-	_, flags := c.sub8(c.A, c.Bus.Read(hl), 0)
+	_, flags := c.sub8(c.A, c.read_mem(hl), 0)
 	c.F = flags
 	c.PC++
 }
@@ -1597,11 +1635,13 @@ func (c *CPU) CP_A() {
 
 func (c *CPU) RET_NZ() {
 	// 0xC0 Return from subroutine if flag Z not set
+	c.tick()
 	if c.F&FLAG_Z != 0 {
 		c.PC++
 		return
 	}
 	c.PC = c.pop()
+	c.tick()
 }
 
 func (c *CPU) POP_BC() {
@@ -1620,12 +1660,14 @@ func (c *CPU) JP_NZ_a16() {
 		return
 	}
 	c.PC = des
+	c.tick()
 }
 
 func (c *CPU) JP_a16() {
 	// 0xC3 Jump to a16
 	des := c.read_word()
 	c.PC = des
+	c.tick()
 }
 
 func (c *CPU) CALL_NZ_a16() {
@@ -1667,16 +1709,19 @@ func (c *CPU) RST_00() {
 
 func (c *CPU) RET_Z() {
 	// 0xC8 Return from subroutine if flag Z is set
+	c.tick()
 	if c.F&FLAG_Z == 0 {
 		c.PC++
 		return
 	}
 	c.PC = c.pop()
+	c.tick()
 }
 
 func (c *CPU) RET() {
 	// 0xC9 Return from subroutine
 	c.PC = c.pop()
+	c.tick()
 }
 
 func (c *CPU) JP_Z_a16() {
@@ -1688,12 +1733,13 @@ func (c *CPU) JP_Z_a16() {
 		return
 	}
 	c.PC = des
+	c.tick()
 }
 
 func (c *CPU) PREFIX() {
 	// 0xCB prefixed instructions, read next byte and perform operation from prefixed table
 	op := c.read_byte()
-	c.Prefixed_Operations[op].Exec()
+	Prefixed_Operations[op].Exec(c)
 }
 
 func (c *CPU) CALL_Z_a16() {
@@ -1737,11 +1783,13 @@ func (c *CPU) RST_08() {
 
 func (c *CPU) RET_NC() {
 	// 0xD0 Return from subroutine if flag C not set
+	c.tick()
 	if c.F&FLAG_C != 0 {
 		c.PC++
 		return
 	}
 	c.PC = c.pop()
+	c.tick()
 }
 
 func (c *CPU) POP_DE() {
@@ -1760,6 +1808,7 @@ func (c *CPU) JP_NC_a16() {
 		return
 	}
 	c.PC = des
+	c.tick()
 }
 
 func (c *CPU) CALL_NC_a16() {
@@ -1796,22 +1845,25 @@ func (c *CPU) RST_10() {
 	// 0xD7 Jump to vector 0x0010
 	c.PC++
 	c.push(c.PC)
-	c.PC = 0x0000
+	c.PC = 0x0010
 }
 
 func (c *CPU) RET_C() {
 	// 0xD8 Return from subroutine if flag C is set
+	c.tick()
 	if c.F&FLAG_C == 0 {
 		c.PC++
 		return
 	}
 	c.PC = c.pop()
+	c.tick()
 }
 
 func (c *CPU) RETI() {
 	// 0xD9 Return from subroutine and enable interrupts
-	//TODO Add interrupt handling
 	c.PC = c.pop()
+	c.tick()
+	c.Status.Interrupt_Enabled = true
 }
 
 func (c *CPU) JP_C_a16() {
@@ -1823,6 +1875,7 @@ func (c *CPU) JP_C_a16() {
 		return
 	}
 	c.PC = des
+	c.tick()
 }
 
 func (c *CPU) CALL_C_a16() {
@@ -1860,7 +1913,7 @@ func (c *CPU) LDH_ADDR_a8_A() {
 	// 0xE0 Store A register to address in High Ram offset by a8
 	n := c.read_byte()
 	addr := 0xff00 | uint16(n)
-	c.Bus.Write(addr, c.A)
+	c.write_mem(addr, c.A)
 	c.PC++
 }
 
@@ -1874,7 +1927,7 @@ func (c *CPU) POP_HL() {
 func (c *CPU) LD_ADDR_C_A() {
 	// 0xE2 Store A register to address in High Ram offset by C
 	addr := 0xff00 | uint16(c.C)
-	c.Bus.Write(addr, c.A)
+	c.write_mem(addr, c.A)
 	c.PC++
 }
 
@@ -1911,6 +1964,8 @@ func (c *CPU) ADD_SP_e8() {
 	res, _ := c.add16(c.SP, uint16(int8(e))) // uint16(int8(e)): convert byte to signed 8bit to unsigned 16bit to preserve 2s complement
 	c.SP = res
 	c.F = (FLAG_H | FLAG_C) & flags // 0011 0000 -> keep H and C flags only
+	c.tick()
+	c.tick()
 	c.PC++
 }
 
@@ -1923,7 +1978,7 @@ func (c *CPU) JP_HL() {
 func (c *CPU) LD_ADDR_a16_A() {
 	// 0xEA Load contents of A to address a16
 	addr := c.read_word()
-	c.Bus.Write(addr, c.A)
+	c.write_mem(addr, c.A)
 	c.PC++
 }
 
@@ -1949,27 +2004,28 @@ func (c *CPU) LDH_A_ADDR_a8() {
 	// 0xF0 Load into A contents of high ram byte a8
 	a := c.read_byte()
 	addr := 0xff00 | uint16(a)
-	c.A = c.Bus.Read(addr)
+	c.A = c.read_mem(addr)
 	c.PC++
 }
 
 func (c *CPU) POP_AF() {
 	// 0xF1 Pop stack into AF register
 	s := c.pop()
-	c.write_r16(AF, s)
+	c.write_r16(AF, s&0xfff0) // low nibble of F is always zero
 	c.PC++
 }
 
 func (c *CPU) LD_A_ADDR_C() {
 	// 0xF2 Load into A contents of high ram byte C
 	addr := 0xff00 | uint16(c.C)
-	c.A = c.Bus.Read(addr)
+	c.A = c.read_mem(addr)
 	c.PC++
 }
 
 func (c *CPU) DI() {
 	// 0xF3 Disable Interrupt
-	//TODO Add interrupt handling
+	c.Status.Interrupt_Enabled = false
+	c.Status.Interrupt_Delayed = false
 	c.PC++
 }
 
@@ -2005,6 +2061,7 @@ func (c *CPU) LD_HL_SP_PLUS_e8() {
 	res, _ := c.add16(c.SP, uint16(int8(e))) // uint16(int8(e)): convert byte to signed 8bit to unsigned 16bit to preserve 2s complement
 	c.write_r16(HL, res)
 	c.F = (FLAG_H | FLAG_C) & flags // 0011 0000 -> keep H and C flags only
+	c.tick()
 	c.PC++
 }
 
@@ -2012,19 +2069,22 @@ func (c *CPU) LD_SP_HL() {
 	// 0xF9 Load HL to SP
 	hl := c.read_r16(HL)
 	c.SP = hl
+	c.tick()
 	c.PC++
 }
 
 func (c *CPU) LD_A_ADDR_a16() {
 	// 0xFA Load contents of address a16 to A
 	addr := c.read_word()
-	c.A = c.Bus.Read(addr)
+	c.A = c.read_mem(addr)
 	c.PC++
 }
 
 func (c *CPU) EI() {
-	// 0xFB Enable interrupt
-	//TODO Add interrupt handling
+	// 0xFB Enable interrupt after the next instruction
+	if !c.Status.Interrupt_Enabled {
+		c.Status.Interrupt_Delayed = true
+	}
 	c.PC++
 }
 
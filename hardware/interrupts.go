@@ -0,0 +1,70 @@
+package hardware
+
+import "fmt"
+
+const (
+	REG_IF = 0xFF0F // Interrupt flag register
+	REG_IE = 0xFFFF // Interrupt enable register
+)
+
+const (
+	INT_VBLANK = uint8(1 << 0) // Vertical blank interrupt, vector 0x0040
+	INT_STAT   = uint8(1 << 1) // LCD status interrupt, vector 0x0048
+	INT_TIMER  = uint8(1 << 2) // Timer overflow interrupt, vector 0x0050
+	INT_SERIAL = uint8(1 << 3) // Serial transfer interrupt, vector 0x0058
+	INT_JOYPAD = uint8(1 << 4) // Joypad interrupt, vector 0x0060
+)
+
+func (c *CPU) Request_interrupt(mask uint8) {
+	c.Bus.Write(REG_IF, c.Bus.Read(REG_IF)|mask)
+}
+
+func (c *CPU) pending_interrupts() uint8 {
+	return c.Bus.Read(REG_IE) & c.Bus.Read(REG_IF) & 0x1f
+}
+
+func (c *CPU) service_interrupt() bool {
+	// Dispatch the highest priority pending interrupt if IME is set
+	if !c.Status.Interrupt_Enabled || c.pending_interrupts() == 0 {
+		return false
+	}
+	c.Status.Interrupt_Enabled = false
+	if c.Status.Halt_Bug {
+		c.Status.Halt_Bug = false
+		c.PC-- // EI then HALT with an interrupt pending returns to the HALT
+	}
+	pc := c.PC
+
+	// Dispatch takes 5 M-cycles, the vector is chosen after the high byte of PC is pushed
+	// so a push overwriting IE can redirect or cancel it (PC ends up at 0x0000)
+	c.tick()
+	c.tick()
+	c.SP--
+	c.write_mem(c.SP, uint8(c.PC>>8))
+	pending := c.pending_interrupts()
+	c.SP--
+	c.write_mem(c.SP, uint8(c.PC))
+	c.tick()
+
+	c.PC = 0x0000
+	for i := uint16(0); i < 5; i++ {
+		mask := uint8(1 << i)
+		if pending&mask != 0 {
+			c.Bus.Write(REG_IF, c.Bus.Read(REG_IF) & ^mask)
+			c.PC = 0x0040 + i*8
+			break
+		}
+	}
+
+	// Describe the dispatch like a call to the vector, int 00 when it was cancelled
+	c.ExecInfo = EXECUTION_INFO{
+		Mneumonic:   fmt.Sprintf("int %02x", c.PC),
+		Address:     pc,
+		AddressMode: MODE_STACK,
+		Effective:   c.SP, // lowest address written
+		Reads:       R_SP | R_PC,
+		Writes:      R_SP | R_PC,
+		Dispatch:    true,
+	}
+	return true
+}
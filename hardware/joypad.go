@@ -0,0 +1,72 @@
+package hardware
+
+import "sync/atomic"
+
+type BUTTON uint8
+
+// Buttons by the P1 line they pull low, directions on the row bit 4 selects and actions on bit 5's
+const (
+	BUTTON_RIGHT BUTTON = 1 << iota
+	BUTTON_LEFT
+	BUTTON_UP
+	BUTTON_DOWN
+	BUTTON_A
+	BUTTON_B
+	BUTTON_SELECT
+	BUTTON_START
+)
+
+type Joypad struct {
+	cpu     *CPU
+	selects byte          // P1 bits 4-5, a 0 selects a row
+	lines   byte          // input lines at the last tick, for the interrupt
+	pressed atomic.Uint32 // buttons held by the host
+}
+
+func NewJoypad(cpu *CPU) *Joypad {
+	// Create the joypad handling P1 on the CPU's bus, it raises no interrupt until attached
+	j := &Joypad{cpu: cpu, lines: 0x0f}
+	cpu.Bus.MapIO(REG_P1, j)
+	return j
+}
+
+func (j *Joypad) Press(b BUTTON) {
+	// Hold buttons down, it may be called from any goroutine
+	for old := j.pressed.Load(); !j.pressed.CompareAndSwap(old, old|uint32(b)); old = j.pressed.Load() {
+	}
+}
+
+func (j *Joypad) Release(b BUTTON) {
+	for old := j.pressed.Load(); !j.pressed.CompareAndSwap(old, old&^uint32(b)); old = j.pressed.Load() {
+	}
+}
+
+func (j *Joypad) input() byte {
+	// Input lines of the selected rows, a pressed button reads 0
+	pressed := byte(j.pressed.Load())
+	lines := byte(0x0f)
+	if j.selects&0x10 == 0 {
+		lines &^= pressed & 0x0f
+	}
+	if j.selects&0x20 == 0 {
+		lines &^= pressed >> 4
+	}
+	return lines
+}
+
+func (j *Joypad) Read(addr uint16) byte {
+	return 0xc0 | j.selects | j.input() // unused bits read as 1
+}
+
+func (j *Joypad) Write(addr uint16, b byte) {
+	j.selects = b & 0x30 // the input lines are read only
+}
+
+func (j *Joypad) Tick(t_states uint8) {
+	// Request the joypad interrupt when an input line goes low
+	lines := j.input()
+	if j.lines&^lines != 0 {
+		j.cpu.Request_interrupt(INT_JOYPAD)
+	}
+	j.lines = lines
+}
@@ -0,0 +1,177 @@
+package hardware
+
+import (
+	"fmt"
+	"strings"
+)
+
+type MODEL uint8
+
+const (
+	MODEL_DMG0 MODEL = iota // early DMG with the original boot ROM
+	MODEL_DMG               // Game Boy
+	MODEL_MGB               // Game Boy Pocket
+	MODEL_SGB               // Super Game Boy
+	MODEL_SGB2              // Super Game Boy 2
+	MODEL_CGB               // Game Boy Color
+	MODEL_AGB               // Game Boy Advance running a Game Boy cartridge
+)
+
+var model_names = []string{"DMG0", "DMG", "MGB", "SGB", "SGB2", "CGB", "AGB"}
+
+func (m MODEL) String() string {
+	if int(m) < len(model_names) {
+		return model_names[m]
+	}
+	return fmt.Sprintf("MODEL(%d)", m)
+}
+
+func (m MODEL) CGB() bool {
+	// Models with CGB hardware: double speed, VRAM and WRAM banks, colour palettes
+	return m == MODEL_CGB || m == MODEL_AGB
+}
+
+func ParseModel(name string) (MODEL, error) {
+	for i, n := range model_names {
+		if strings.EqualFold(n, name) {
+			return MODEL(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown hardware model %q, expected one of %s", name, strings.Join(model_names, ", "))
+}
+
+type boot_state struct {
+	A, F, B, C, D, E, H, L uint8
+	DIV, STAT, NR52        uint8
+}
+
+// Registers each boot ROM leaves, F assumes a non zero header checksum and SGB and CGB DIV
+// values are approximate since they depend on the boot animation
+var boot_states = [...]boot_state{
+	MODEL_DMG0: {A: 0x01, F: 0x00, B: 0xFF, C: 0x13, D: 0x00, E: 0xC1, H: 0x84, L: 0x03, DIV: 0x18, STAT: 0x81, NR52: 0xF1},
+	MODEL_DMG:  {A: 0x01, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D, DIV: 0xAB, STAT: 0x85, NR52: 0xF1},
+	MODEL_MGB:  {A: 0xFF, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D, DIV: 0xAB, STAT: 0x85, NR52: 0xF1},
+	MODEL_SGB:  {A: 0x01, F: 0x00, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60, DIV: 0x00, STAT: 0x85, NR52: 0xF0},
+	MODEL_SGB2: {A: 0xFF, F: 0x00, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60, DIV: 0x00, STAT: 0x85, NR52: 0xF0},
+	MODEL_CGB:  {A: 0x11, F: 0x80, B: 0x00, C: 0x00, D: 0xFF, E: 0x56, H: 0x00, L: 0x0D, DIV: 0x00, STAT: 0x85, NR52: 0xF1},
+	MODEL_AGB:  {A: 0x11, F: 0x00, B: 0x01, C: 0x00, D: 0xFF, E: 0x56, H: 0x00, L: 0x0D, DIV: 0x00, STAT: 0x85, NR52: 0xF1},
+}
+
+// I/O registers with the same post boot value on every model
+var boot_io = map[uint16]uint8{
+	0xFF00: 0xCF, // P1
+	0xFF01: 0x00, // SB
+	0xFF02: 0x7E, // SC
+	0xFF05: 0x00, // TIMA
+	0xFF06: 0x00, // TMA
+	0xFF07: 0xF8, // TAC
+	0xFF0F: 0xE1, // IF
+	0xFF10: 0x80, // NR10
+	0xFF11: 0xBF, // NR11
+	0xFF12: 0xF3, // NR12
+	0xFF13: 0xFF, // NR13
+	0xFF14: 0xBF, // NR14
+	0xFF16: 0x3F, // NR21
+	0xFF17: 0x00, // NR22
+	0xFF18: 0xFF, // NR23
+	0xFF19: 0xBF, // NR24
+	0xFF1A: 0x7F, // NR30
+	0xFF1B: 0xFF, // NR31
+	0xFF1C: 0x9F, // NR32
+	0xFF1D: 0xFF, // NR33
+	0xFF1E: 0xBF, // NR34
+	0xFF20: 0xFF, // NR41
+	0xFF21: 0x00, // NR42
+	0xFF22: 0x00, // NR43
+	0xFF23: 0xBF, // NR44
+	0xFF24: 0x77, // NR50
+	0xFF25: 0xF3, // NR51
+	0xFF40: 0x91, // LCDC
+	0xFF42: 0x00, // SCY
+	0xFF43: 0x00, // SCX
+	0xFF44: 0x00, // LY
+	0xFF45: 0x00, // LYC
+	0xFF46: 0xFF, // DMA
+	0xFF47: 0xFC, // BGP
+	0xFF48: 0xFF, // OBP0
+	0xFF49: 0xFF, // OBP1
+	0xFF4A: 0x00, // WY
+	0xFF4B: 0x00, // WX
+	0xFF4D: 0xFF, // KEY1
+	0xFF4F: 0xFF, // VBK
+	0xFF51: 0xFF, // HDMA1
+	0xFF52: 0xFF, // HDMA2
+	0xFF53: 0xFF, // HDMA3
+	0xFF54: 0xFF, // HDMA4
+	0xFF55: 0xFF, // HDMA5
+	0xFF56: 0xFF, // RP
+	0xFF68: 0xFF, // BCPS
+	0xFF69: 0xFF, // BCPD
+	0xFF6A: 0xFF, // OCPS
+	0xFF6B: 0xFF, // OCPD
+	0xFF70: 0xFF, // SVBK
+	0xFFFF: 0x00, // IE
+}
+
+// I/O registers that read differently on CGB hardware
+var boot_io_cgb = map[uint16]uint8{
+	0xFF02: 0x7F, // SC, the fast clock bit is implemented
+	0xFF4D: 0x7E, // KEY1
+	0xFF4F: 0xFE, // VBK
+	0xFF56: 0x3E, // RP
+	0xFF70: 0xF8, // SVBK
+}
+
+func (c *CPU) Reset(model MODEL) {
+	// Put the CPU and I/O registers in the state the model's boot ROM leaves them in,
+	// call it after loading the cartridge since DMG flags depend on the header checksum
+	s := boot_states[model]
+	c.Model = model
+	c.A, c.F, c.B, c.C, c.D, c.E, c.H, c.L = s.A, s.F, s.B, s.C, s.D, s.E, s.H, s.L
+	if (model == MODEL_DMG || model == MODEL_MGB) && c.Bus.Read(0x014D) == 0 {
+		c.F &= ^(FLAG_H | FLAG_C)
+	}
+	c.SP = 0xFFFE
+	c.PC = 0x0100
+	c.Status = CPU_STATUS{}
+	c.Fault = nil
+
+	if model.CGB() {
+		if c.Infrared == nil {
+			c.Infrared = NewInfraredPort(c) // kept across resets with its link
+		}
+		c.Infrared.reset()
+		c.Speed = &SpeedSwitch{} // normal speed after a reset
+		c.Bus.MapIO(REG_KEY1, c.Speed)
+	} else {
+		if c.Infrared != nil {
+			c.Bus.MapIO(REG_RP, nil) // RP is a plain register again
+			c.Infrared = nil
+		}
+		if c.Speed != nil {
+			c.Bus.MapIO(REG_KEY1, nil)
+			c.Speed = nil
+		}
+	}
+	c.post_boot(boot_io)
+	if model.CGB() {
+		c.post_boot(boot_io_cgb)
+	}
+	if c.Timer != nil {
+		c.Timer.SetDIV(s.DIV)
+	} else {
+		c.Bus.Write(REG_DIV, s.DIV)
+	}
+	c.Bus.Write(0xFF41, s.STAT)
+	c.Bus.Write(0xFF26, s.NR52)
+}
+
+func (c *CPU) post_boot(regs map[uint16]uint8) {
+	// Write post boot register values except to the CGB devices, writing RP would flash the LED
+	for addr, b := range regs {
+		if (addr == REG_RP && c.Infrared != nil) || (addr == REG_KEY1 && c.Speed != nil) {
+			continue
+		}
+		c.Bus.Write(addr, b)
+	}
+}
@@ -5,7 +5,7 @@ func (c *CPU) RLC_B() {
 	carry := c.B >> 7
 	c.B = c.B<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.B == 0 {
 		c.Flag_set(FLAG_Z)
@@ -18,7 +18,7 @@ func (c *CPU) RLC_C() {
 	carry := c.C >> 7
 	c.C = c.C<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.C == 0 {
 		c.Flag_set(FLAG_Z)
@@ -31,7 +31,7 @@ func (c *CPU) RLC_D() {
 	carry := c.D >> 7
 	c.D = c.D<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.D == 0 {
 		c.Flag_set(FLAG_Z)
@@ -44,7 +44,7 @@ func (c *CPU) RLC_E() {
 	carry := c.E >> 7
 	c.E = c.E<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.E == 0 {
 		c.Flag_set(FLAG_Z)
@@ -57,7 +57,7 @@ func (c *CPU) RLC_H() {
 	carry := c.H >> 7
 	c.H = c.H<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.H == 0 {
 		c.Flag_set(FLAG_Z)
@@ -70,7 +70,7 @@ func (c *CPU) RLC_L() {
 	carry := c.L >> 7
 	c.L = c.L<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.L == 0 {
 		c.Flag_set(FLAG_Z)
@@ -81,13 +81,13 @@ func (c *CPU) RLC_L() {
 func (c *CPU) RLC_ADDR_HL() {
 	// 0x06 Rotate register [HL] left with carry
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	carry := b >> 7
 	b = b<<1 | carry
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if b == 0 {
 		c.Flag_set(FLAG_Z)
@@ -97,12 +97,12 @@ func (c *CPU) RLC_ADDR_HL() {
 
 func (c *CPU) RLC_A() {
 	// 0x07 Rotate register A left with carry
-	carry := c.E >> 7
-	c.E = c.E<<1 | carry
+	carry := c.A >> 7
+	c.A = c.A<<1 | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
-	if c.E == 0 {
+	if c.A == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -113,6 +113,7 @@ func (c *CPU) RRC_B() {
 	carry := c.B & 0x01
 	c.B = c.B >> 1
 	c.B |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.B == 0 {
 		c.Flag_set(FLAG_Z)
@@ -125,6 +126,7 @@ func (c *CPU) RRC_C() {
 	carry := c.C & 0x01
 	c.C = c.C >> 1
 	c.C |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.C == 0 {
 		c.Flag_set(FLAG_Z)
@@ -137,6 +139,7 @@ func (c *CPU) RRC_D() {
 	carry := c.D & 0x01
 	c.D = c.D >> 1
 	c.D |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.D == 0 {
 		c.Flag_set(FLAG_Z)
@@ -149,6 +152,7 @@ func (c *CPU) RRC_E() {
 	carry := c.E & 0x01
 	c.E = c.E >> 1
 	c.E |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.E == 0 {
 		c.Flag_set(FLAG_Z)
@@ -161,6 +165,7 @@ func (c *CPU) RRC_H() {
 	carry := c.H & 0x01
 	c.H = c.H >> 1
 	c.H |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.H == 0 {
 		c.Flag_set(FLAG_Z)
@@ -173,6 +178,7 @@ func (c *CPU) RRC_L() {
 	carry := c.L & 0x01
 	c.L = c.L >> 1
 	c.L |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if c.L == 0 {
 		c.Flag_set(FLAG_Z)
@@ -183,13 +189,14 @@ func (c *CPU) RRC_L() {
 func (c *CPU) RRC_ADDR_HL() {
 	// 0x0E Rotate register [HL] right with carry
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	carry := b & 0x01
 	b = b >> 1
 	b |= carry << 7
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
 	if b == 0 {
 		c.Flag_set(FLAG_Z)
@@ -199,11 +206,12 @@ func (c *CPU) RRC_ADDR_HL() {
 
 func (c *CPU) RRC_A() {
 	// 0x0A Rotate register A right with carry
-	carry := c.E & 0x01
-	c.E = c.E >> 1
-	c.E |= carry << 7
+	carry := c.A & 0x01
+	c.A = c.A >> 1
+	c.A |= carry << 7
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(carry << 4)
-	if c.E == 0 {
+	if c.A == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -215,7 +223,7 @@ func (c *CPU) RL_B() {
 	carry := (c.F & FLAG_C) >> 4
 	c.B = (c.B << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if c.B == 0 {
 		c.Flag_set(FLAG_Z)
@@ -229,7 +237,7 @@ func (c *CPU) RL_C() {
 	carry := (c.F & FLAG_C) >> 4
 	c.C = (c.C << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if c.C == 0 {
 		c.Flag_set(FLAG_Z)
@@ -243,7 +251,7 @@ func (c *CPU) RL_D() {
 	carry := (c.F & FLAG_C) >> 4
 	c.D = (c.D << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if c.D == 0 {
 		c.Flag_set(FLAG_Z)
@@ -257,7 +265,7 @@ func (c *CPU) RL_E() {
 	carry := (c.F & FLAG_C) >> 4
 	c.E = (c.E << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if c.E == 0 {
 		c.Flag_set(FLAG_Z)
@@ -271,7 +279,7 @@ func (c *CPU) RL_H() {
 	carry := (c.F & FLAG_C) >> 4
 	c.H = (c.H << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if c.H == 0 {
 		c.Flag_set(FLAG_Z)
@@ -285,7 +293,7 @@ func (c *CPU) RL_L() {
 	carry := (c.F & FLAG_C) >> 4
 	c.L = (c.L << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if c.L == 0 {
 		c.Flag_set(FLAG_Z)
@@ -296,14 +304,14 @@ func (c *CPU) RL_L() {
 func (c *CPU) RL_ADDR_HL() {
 	// 0x16 Rotate left [HL] with [HL][0] = C and C = [HL][7]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit_7 := uint8(b & 0x80)
 	carry := (c.F & FLAG_C) >> 4
 	b = (b << 1) | carry
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
 	if b == 0 {
 		c.Flag_set(FLAG_Z)
@@ -313,13 +321,13 @@ func (c *CPU) RL_ADDR_HL() {
 
 func (c *CPU) RL_A() {
 	// 0x17 Rotate left A with A[0] = C and C = A[7]
-	bit_7 := uint8(c.E & 0x80)
+	bit_7 := uint8(c.A & 0x80)
 	carry := (c.F & FLAG_C) >> 4
-	c.E = (c.E << 1) | carry
+	c.A = (c.A << 1) | carry
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_7 >> 3)
-	if c.E == 0 {
+	if c.A == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -331,7 +339,7 @@ func (c *CPU) RR_B() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.B = (c.B >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.B == 0 {
 		c.Flag_set(FLAG_Z)
@@ -345,7 +353,7 @@ func (c *CPU) RR_C() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.C = (c.C >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.C == 0 {
 		c.Flag_set(FLAG_Z)
@@ -359,7 +367,7 @@ func (c *CPU) RR_D() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.D = (c.D >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.D == 0 {
 		c.Flag_set(FLAG_Z)
@@ -373,7 +381,7 @@ func (c *CPU) RR_E() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.E = (c.E >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.E == 0 {
 		c.Flag_set(FLAG_Z)
@@ -387,7 +395,7 @@ func (c *CPU) RR_H() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.H = (c.H >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.H == 0 {
 		c.Flag_set(FLAG_Z)
@@ -401,7 +409,7 @@ func (c *CPU) RR_L() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.L = (c.L >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.L == 0 {
 		c.Flag_set(FLAG_Z)
@@ -412,14 +420,14 @@ func (c *CPU) RR_L() {
 func (c *CPU) RR_ADDR_HL() {
 	// 0x1E Rotate Right [HL] with [HL][0] -> C and C -> [HL][7]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit_0 := uint8(b & 0x01)
 	carry := (c.F & FLAG_C) << 3
 	b = (b >> 1) | carry
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if b == 0 {
 		c.Flag_set(FLAG_Z)
@@ -433,7 +441,7 @@ func (c *CPU) RR_A() {
 	carry := (c.F & FLAG_C) << 3
 
 	c.A = (c.A >> 1) | carry
-	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H)
+	c.Flag_reset(FLAG_Z | FLAG_N | FLAG_H | FLAG_C)
 	c.Flag_set(bit_0 << 4)
 	if c.A == 0 {
 		c.Flag_set(FLAG_Z)
@@ -516,11 +524,11 @@ func (c *CPU) SLA_L() {
 func (c *CPU) SLA_ADDR_HL() {
 	// 0x26 Shift [HL] Left Arithmetically
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit_7 := b & 0x80
 	b = b << 1
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
 	c.F = 0
 	c.Flag_set(bit_7 >> 3)
@@ -617,11 +625,11 @@ func (c *CPU) SRA_L() {
 func (c *CPU) SRA_ADDR_HL() {
 	// 0x2E Shift [HL] Right Arithmetically
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit_0 := b & 0x01
 	b = (b >> 1) | (b & 0x80)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
 	c.F = 0
 	c.Flag_set(bit_0 << 4)
@@ -706,10 +714,10 @@ func (c *CPU) SWAP_L() {
 func (c *CPU) SWAP_ADDR_HL() {
 	// 0x36 Swap low and high nibble of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b = (b&0x0f)<<4 | (b&0xf0)>>4
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
 	c.F = 0
 	if b == 0 {
@@ -803,11 +811,11 @@ func (c *CPU) SRL_L() {
 func (c *CPU) SRL_ADDR_HL() {
 	// 0x3E Shift [HL] Right Logically
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit_0 := b & 0x01
 	b = b >> 1
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 
 	c.F = 0
 	c.Flag_set(bit_0 << 4)
@@ -833,7 +841,7 @@ func (c *CPU) BIT_0_B() {
 	// 0x40 Check if bit 0 of register B is set
 	bit := c.B & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -843,7 +851,7 @@ func (c *CPU) BIT_0_C() {
 	// 0x41 Check if bit 0 of register C is set
 	bit := c.C & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -853,7 +861,7 @@ func (c *CPU) BIT_0_D() {
 	// 0x42 Check if bit 0 of register D is set
 	bit := c.D & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -863,7 +871,7 @@ func (c *CPU) BIT_0_E() {
 	// 0x43 Check if bit 0 of register E is set
 	bit := c.E & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -873,7 +881,7 @@ func (c *CPU) BIT_0_H() {
 	// 0x44 Check if bit 0 of register H is set
 	bit := c.H & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -883,7 +891,7 @@ func (c *CPU) BIT_0_L() {
 	// 0x45 Check if bit 0 of register L is set
 	bit := c.L & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -892,12 +900,12 @@ func (c *CPU) BIT_0_L() {
 func (c *CPU) BIT_0_ADDR_HL() {
 	// 0x46 Check if bit 0 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 0)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -907,7 +915,7 @@ func (c *CPU) BIT_0_A() {
 	// 0x47 Check if bit 0 of register A is set
 	bit := c.A & (1 << 0)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -917,7 +925,7 @@ func (c *CPU) BIT_1_B() {
 	// 0x48 Check if bit 1 of register B is set
 	bit := c.B & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -927,7 +935,7 @@ func (c *CPU) BIT_1_C() {
 	// 0x49 Check if bit 1 of register C is set
 	bit := c.C & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -937,7 +945,7 @@ func (c *CPU) BIT_1_D() {
 	// 0x4A Check if bit 1 of register D is set
 	bit := c.D & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -947,7 +955,7 @@ func (c *CPU) BIT_1_E() {
 	// 0x4B Check if bit 1 of register E is set
 	bit := c.E & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -957,7 +965,7 @@ func (c *CPU) BIT_1_H() {
 	// 0x4C Check if bit 1 of register H is set
 	bit := c.H & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -967,7 +975,7 @@ func (c *CPU) BIT_1_L() {
 	// 0x4D Check if bit 1 of register L is set
 	bit := c.L & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -976,12 +984,12 @@ func (c *CPU) BIT_1_L() {
 func (c *CPU) BIT_1_ADDR_HL() {
 	// 0x4E Check if bit 1 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 1)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -991,7 +999,7 @@ func (c *CPU) BIT_1_A() {
 	// 0x4F Check if bit 1 of register A is set
 	bit := c.A & (1 << 1)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1001,7 +1009,7 @@ func (c *CPU) BIT_2_B() {
 	// 0x50 Check if bit 2 of register B is set
 	bit := c.B & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1011,7 +1019,7 @@ func (c *CPU) BIT_2_C() {
 	// 0x51 Check if bit 2 of register C is set
 	bit := c.C & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1021,7 +1029,7 @@ func (c *CPU) BIT_2_D() {
 	// 0x52 Check if bit 2 of register D is set
 	bit := c.D & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1031,7 +1039,7 @@ func (c *CPU) BIT_2_E() {
 	// 0x53 Check if bit 2 of register E is set
 	bit := c.E & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1041,7 +1049,7 @@ func (c *CPU) BIT_2_H() {
 	// 0x54 Check if bit 2 of register H is set
 	bit := c.H & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1051,7 +1059,7 @@ func (c *CPU) BIT_2_L() {
 	// 0x55 Check if bit 2 of register L is set
 	bit := c.L & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1060,11 +1068,11 @@ func (c *CPU) BIT_2_L() {
 func (c *CPU) BIT_2_ADDR_HL() {
 	// 0x56 Check if bit 2 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1074,7 +1082,7 @@ func (c *CPU) BIT_2_A() {
 	// 0x57 Check if bit 2 of register A is set
 	bit := c.A & (1 << 2)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1084,7 +1092,7 @@ func (c *CPU) BIT_3_B() {
 	// 0x58 Check if bit 3 of register B is set
 	bit := c.B & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1094,7 +1102,7 @@ func (c *CPU) BIT_3_C() {
 	// 0x59 Check if bit 3 of register C is set
 	bit := c.C & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1104,7 +1112,7 @@ func (c *CPU) BIT_3_D() {
 	// 0x5A Check if bit 3 of register D is set
 	bit := c.D & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1114,7 +1122,7 @@ func (c *CPU) BIT_3_E() {
 	// 0x5B Check if bit 3 of register E is set
 	bit := c.E & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1124,7 +1132,7 @@ func (c *CPU) BIT_3_H() {
 	// 0x5C Check if bit 3 of register H is set
 	bit := c.H & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1134,7 +1142,7 @@ func (c *CPU) BIT_3_L() {
 	// 0x5D Check if bit 3 of register L is set
 	bit := c.L & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1143,12 +1151,12 @@ func (c *CPU) BIT_3_L() {
 func (c *CPU) BIT_3_ADDR_HL() {
 	// 0x5E Check if bit 3 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 3)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1158,7 +1166,7 @@ func (c *CPU) BIT_3_A() {
 	// 0x5F Check if bit 3 of register A is set
 	bit := c.A & (1 << 3)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1168,7 +1176,7 @@ func (c *CPU) BIT_4_B() {
 	// 0x60 Check if bit 4 of register B is set
 	bit := c.B & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1178,7 +1186,7 @@ func (c *CPU) BIT_4_C() {
 	// 0x61 Check if bit 4 of register C is set
 	bit := c.C & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1188,7 +1196,7 @@ func (c *CPU) BIT_4_D() {
 	// 0x62 Check if bit 4 of register D is set
 	bit := c.D & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1198,7 +1206,7 @@ func (c *CPU) BIT_4_E() {
 	// 0x63 Check if bit 4 of register E is set
 	bit := c.E & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1208,7 +1216,7 @@ func (c *CPU) BIT_4_H() {
 	// 0x64 Check if bit 4 of register H is set
 	bit := c.H & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1218,7 +1226,7 @@ func (c *CPU) BIT_4_L() {
 	// 0x65 Check if bit 4 of register L is set
 	bit := c.L & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1227,12 +1235,12 @@ func (c *CPU) BIT_4_L() {
 func (c *CPU) BIT_4_ADDR_HL() {
 	// 0x66 Check if bit 4 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 4)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1242,7 +1250,7 @@ func (c *CPU) BIT_4_A() {
 	// 0x67 Check if bit 4 of register A is set
 	bit := c.A & (1 << 4)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1252,7 +1260,7 @@ func (c *CPU) BIT_5_B() {
 	// 0x68 Check if bit 5 of register B is set
 	bit := c.B & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1262,7 +1270,7 @@ func (c *CPU) BIT_5_C() {
 	// 0x69 Check if bit 5 of register C is set
 	bit := c.C & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1272,7 +1280,7 @@ func (c *CPU) BIT_5_D() {
 	// 0x6A Check if bit 5 of register D is set
 	bit := c.D & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1282,7 +1290,7 @@ func (c *CPU) BIT_5_E() {
 	// 0x6B Check if bit 5 of register E is set
 	bit := c.E & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1292,7 +1300,7 @@ func (c *CPU) BIT_5_H() {
 	// 0x6C Check if bit 5 of register H is set
 	bit := c.H & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1302,7 +1310,7 @@ func (c *CPU) BIT_5_L() {
 	// 0x6D Check if bit 5 of register L is set
 	bit := c.L & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1311,12 +1319,12 @@ func (c *CPU) BIT_5_L() {
 func (c *CPU) BIT_5_ADDR_HL() {
 	// 0x6E Check if bit 5 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 5)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1326,7 +1334,7 @@ func (c *CPU) BIT_5_A() {
 	// 0x6F Check if bit 5 of register A is set
 	bit := c.A & (1 << 5)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1336,7 +1344,7 @@ func (c *CPU) BIT_6_B() {
 	// 0x70 Check if bit 6 of register B is set
 	bit := c.B & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1346,7 +1354,7 @@ func (c *CPU) BIT_6_C() {
 	// 0x71 Check if bit 6 of register C is set
 	bit := c.C & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1356,7 +1364,7 @@ func (c *CPU) BIT_6_D() {
 	// 0x72 Check if bit 6 of register D is set
 	bit := c.D & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1366,7 +1374,7 @@ func (c *CPU) BIT_6_E() {
 	// 0x73 Check if bit 6 of register E is set
 	bit := c.E & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1376,7 +1384,7 @@ func (c *CPU) BIT_6_H() {
 	// 0x74 Check if bit 6 of register H is set
 	bit := c.H & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1386,7 +1394,7 @@ func (c *CPU) BIT_6_L() {
 	// 0x75 Check if bit 6 of register L is set
 	bit := c.L & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1395,12 +1403,12 @@ func (c *CPU) BIT_6_L() {
 func (c *CPU) BIT_6_ADDR_HL() {
 	// 0x76 Check if bit 6 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 6)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1410,7 +1418,7 @@ func (c *CPU) BIT_6_A() {
 	// 0x77 Check if bit 6 of register A is set
 	bit := c.A & (1 << 6)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1420,7 +1428,7 @@ func (c *CPU) BIT_7_B() {
 	// 0x78 Check if bit 7 of register B is set
 	bit := c.B & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1430,7 +1438,7 @@ func (c *CPU) BIT_7_C() {
 	// 0x79 Check if bit 7 of register C is set
 	bit := c.C & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1440,7 +1448,7 @@ func (c *CPU) BIT_7_D() {
 	// 0x7A Check if bit 7 of register D is set
 	bit := c.D & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1450,7 +1458,7 @@ func (c *CPU) BIT_7_E() {
 	// 0x7B Check if bit 7 of register E is set
 	bit := c.E & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1460,7 +1468,7 @@ func (c *CPU) BIT_7_H() {
 	// 0x7C Check if bit 7 of register H is set
 	bit := c.H & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1470,7 +1478,7 @@ func (c *CPU) BIT_7_L() {
 	// 0x7D Check if bit 7 of register L is set
 	bit := c.L & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1479,12 +1487,12 @@ func (c *CPU) BIT_7_L() {
 func (c *CPU) BIT_7_ADDR_HL() {
 	// 0x7E Check if bit 7 of register [HL] is set
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	bit := b & (1 << 7)
 
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1494,7 +1502,7 @@ func (c *CPU) BIT_7_A() {
 	// 0x7F Check if bit 7 of register A is set
 	bit := c.A & (1 << 7)
 	c.F = (c.F & FLAG_C) | FLAG_H
-	if bit != 0 {
+	if bit == 0 {
 		c.Flag_set(FLAG_Z)
 	}
 	c.PC++
@@ -1539,10 +1547,10 @@ func (c *CPU) RES_0_L() {
 func (c *CPU) RES_0_ADDR_HL() {
 	// 0x86 Reset bit 0 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 0) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1591,10 +1599,10 @@ func (c *CPU) RES_1_L() {
 func (c *CPU) RES_1_ADDR_HL() {
 	// 0x8E Reset bit 1 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 1) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1643,10 +1651,10 @@ func (c *CPU) RES_2_L() {
 func (c *CPU) RES_2_ADDR_HL() {
 	// 0x96 Reset bit 2 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 2) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1695,10 +1703,10 @@ func (c *CPU) RES_3_L() {
 func (c *CPU) RES_3_ADDR_HL() {
 	// 0x9E Reset bit 3 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 3) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1747,10 +1755,10 @@ func (c *CPU) RES_4_L() {
 func (c *CPU) RES_4_ADDR_HL() {
 	// 0xA6 Reset bit 4 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 4) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1799,10 +1807,10 @@ func (c *CPU) RES_5_L() {
 func (c *CPU) RES_5_ADDR_HL() {
 	// 0xAE Reset bit 5 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 5) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1851,10 +1859,10 @@ func (c *CPU) RES_6_L() {
 func (c *CPU) RES_6_ADDR_HL() {
 	// 0xB6 Reset bit 6 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 6) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1903,10 +1911,10 @@ func (c *CPU) RES_7_L() {
 func (c *CPU) RES_7_ADDR_HL() {
 	// 0xBE Reset bit 7 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b &= ((1 << 7) ^ (0xff))
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -1955,10 +1963,10 @@ func (c *CPU) SET_0_L() {
 func (c *CPU) SET_0_ADDR_HL() {
 	// 0xC6 Set bit 0 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 0)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2007,10 +2015,10 @@ func (c *CPU) SET_1_L() {
 func (c *CPU) SET_1_ADDR_HL() {
 	// 0xCE Set bit 1 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 1)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2059,10 +2067,10 @@ func (c *CPU) SET_2_L() {
 func (c *CPU) SET_2_ADDR_HL() {
 	// 0xD6 Set bit 2 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 2)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2111,10 +2119,10 @@ func (c *CPU) SET_3_L() {
 func (c *CPU) SET_3_ADDR_HL() {
 	// 0xDE Set bit 3 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 3)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2163,10 +2171,10 @@ func (c *CPU) SET_4_L() {
 func (c *CPU) SET_4_ADDR_HL() {
 	// 0xE6 Set bit 4 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 4)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2215,10 +2223,10 @@ func (c *CPU) SET_5_L() {
 func (c *CPU) SET_5_ADDR_HL() {
 	// 0xEE Set bit 5 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 5)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2267,10 +2275,10 @@ func (c *CPU) SET_6_L() {
 func (c *CPU) SET_6_ADDR_HL() {
 	// 0xF6 Set bit 6 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 6)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
@@ -2319,10 +2327,10 @@ func (c *CPU) SET_7_L() {
 func (c *CPU) SET_7_ADDR_HL() {
 	// 0xFE Set bit 7 of register [HL]
 	hl := c.read_r16(HL)
-	b := c.Bus.Read(hl)
+	b := c.read_mem(hl)
 
 	b |= (1 << 7)
-	c.Bus.Write(hl, b)
+	c.write_mem(hl, b)
 	c.PC++
 }
 
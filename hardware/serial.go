@@ -0,0 +1,62 @@
+package hardware
+
+const (
+	REG_SB = 0xFF01 // Serial transfer data
+	REG_SC = 0xFF02 // Serial transfer control
+)
+
+// T-states to shift out 8 bits with the internal 8192 Hz clock
+const SERIAL_TRANSFER_T_STATES = 8 * 512
+
+type Serial struct {
+	cpu     *CPU
+	sb, sc  byte
+	elapsed uint32 // T-states spent on the transfer in progress
+
+	Output     []byte       // every byte shifted out since power on
+	OnTransfer func(b byte) // host callback invoked for each byte shifted out
+}
+
+func NewSerial(cpu *CPU) *Serial {
+	// Create a serial port handling SB and SC on the CPU's bus, it is not clocked until attached
+	s := &Serial{cpu: cpu, sc: 0x7e}
+	cpu.Bus.MapIO(REG_SB, s)
+	cpu.Bus.MapIO(REG_SC, s)
+	return s
+}
+
+func (s *Serial) Read(addr uint16) byte {
+	if addr == REG_SB {
+		return s.sb
+	}
+	return s.sc | 0x7e // unused bits read as 1
+}
+
+func (s *Serial) Write(addr uint16, b byte) {
+	if addr == REG_SB {
+		s.sb = b
+		return
+	}
+	s.sc = b
+	s.elapsed = 0
+}
+
+func (s *Serial) Tick(t_states uint8) {
+	// Complete a transfer started on the internal clock, no link partner is connected
+	if s.sc&0x81 != 0x81 {
+		return
+	}
+	s.elapsed += uint32(t_states)
+	if s.elapsed < SERIAL_TRANSFER_T_STATES {
+		return
+	}
+	s.elapsed = 0
+
+	s.Output = append(s.Output, s.sb)
+	if s.OnTransfer != nil {
+		s.OnTransfer(s.sb)
+	}
+	s.sb = 0xff // an unconnected line reads as 1s
+	s.sc &= 0x7f
+	s.cpu.Request_interrupt(INT_SERIAL)
+}
@@ -0,0 +1,242 @@
+package hardware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Runs the SingleStepTests SM83 vectors, one JSON file per opcode, checking the final state and
+// every M-cycle's bus access. The full suite is hundreds of MB, so testdata/sm83 keeps 25 vectors
+// of the opcodes it caught bugs in and SM83_TESTS names a full checkout, which must cover every opcode.
+
+type sm83State struct {
+	PC  uint16     `json:"pc"`
+	SP  uint16     `json:"sp"`
+	A   uint8      `json:"a"`
+	B   uint8      `json:"b"`
+	C   uint8      `json:"c"`
+	D   uint8      `json:"d"`
+	E   uint8      `json:"e"`
+	F   uint8      `json:"f"`
+	H   uint8      `json:"h"`
+	L   uint8      `json:"l"`
+	IME uint8      `json:"ime"`
+	IE  *uint8     `json:"ie"`
+	RAM [][2]int64 `json:"ram"`
+}
+
+type sm83Test struct {
+	Name    string       `json:"name"`
+	Initial sm83State    `json:"initial"`
+	Final   sm83State    `json:"final"`
+	Cycles  []*sm83Cycle `json:"cycles"`
+}
+
+// One M-cycle of bus activity, a nil entry is an internal cycle
+type sm83Cycle struct {
+	Addr uint16
+	Data uint8
+	Kind string // "r-m" for a read, "-wm" for a write
+}
+
+func (c *sm83Cycle) String() string {
+	if c == nil {
+		return "internal"
+	}
+	return fmt.Sprintf("%04x %02x %s", c.Addr, c.Data, c.Kind)
+}
+
+func (c *sm83Cycle) UnmarshalJSON(data []byte) error {
+	var raw [3]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[0], &c.Addr); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &c.Data); err != nil {
+		return err
+	}
+	return json.Unmarshal(raw[2], &c.Kind)
+}
+
+// Flat RAM recording each access against its M-cycle, the CPU ticks before it touches the bus
+type sm83Bus struct {
+	cpu       *CPU
+	ram       *RAM
+	recording bool
+	cycles    []*sm83Cycle
+	extra     []string // accesses sharing an M-cycle with another
+}
+
+func (b *sm83Bus) record(addr uint16, data uint8, kind string) {
+	if !b.recording {
+		return
+	}
+	m := int(b.cpu.Cycles/4) - 1
+	for len(b.cycles) <= m {
+		b.cycles = append(b.cycles, nil)
+	}
+	access := &sm83Cycle{addr, data, kind}
+	if m < 0 || b.cycles[m] != nil {
+		b.extra = append(b.extra, fmt.Sprintf("M%d %v", m, access))
+		return
+	}
+	b.cycles[m] = access
+}
+
+func (b *sm83Bus) Read(addr uint16) byte {
+	v := b.ram.Read(addr)
+	b.record(addr, v, "r-m")
+	return v
+}
+
+func (b *sm83Bus) Write(addr uint16, v byte) {
+	b.ram.Write(addr, v)
+	b.record(addr, v, "-wm")
+}
+
+func (s *sm83State) load(cpu *CPU) {
+	cpu.PC, cpu.SP = s.PC, s.SP
+	cpu.A, cpu.B, cpu.C, cpu.D, cpu.E, cpu.F, cpu.H, cpu.L = s.A, s.B, s.C, s.D, s.E, s.F, s.H, s.L
+	cpu.Status.Interrupt_Enabled = s.IME != 0
+	cpu.Bus.Write(REG_IF, 0x00) // the vectors assume no interrupt is pending
+	if s.IE != nil {
+		cpu.Bus.Write(REG_IE, *s.IE)
+	}
+	for _, m := range s.RAM {
+		cpu.Bus.Write(uint16(m[0]), uint8(m[1]))
+	}
+}
+
+func (s *sm83State) diff(cpu *CPU) []string {
+	diffs := []string{}
+	check := func(name string, got, want uint16) {
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("%s=%02x want %02x", name, got, want))
+		}
+	}
+	check("PC", cpu.PC, s.PC)
+	check("SP", cpu.SP, s.SP)
+	check("A", uint16(cpu.A), uint16(s.A))
+	check("F", uint16(cpu.F), uint16(s.F))
+	check("B", uint16(cpu.B), uint16(s.B))
+	check("C", uint16(cpu.C), uint16(s.C))
+	check("D", uint16(cpu.D), uint16(s.D))
+	check("E", uint16(cpu.E), uint16(s.E))
+	check("H", uint16(cpu.H), uint16(s.H))
+	check("L", uint16(cpu.L), uint16(s.L))
+	ime := uint16(0)
+	if cpu.Status.Interrupt_Enabled {
+		ime = 1
+	}
+	check("IME", ime, uint16(s.IME))
+	for _, m := range s.RAM {
+		check(fmt.Sprintf("[%04x]", m[0]), uint16(cpu.Bus.Read(uint16(m[0]))), uint16(m[1]))
+	}
+	return diffs
+}
+
+func runSM83File(t *testing.T, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []sm83Test
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	failed := 0
+	for _, test := range tests {
+		// the vectors treat the whole address space as flat RAM
+		cpu := NewCPU()
+		bus := &sm83Bus{cpu: cpu, ram: NewRAM(0x0000, MEM_SIZE)}
+		for r := REGION(0); r < REGION_COUNT; r++ {
+			cpu.Bus.Map(r, bus)
+		}
+		test.Initial.load(cpu)
+		bus.recording = true
+		cpu.Step()
+		bus.recording = false
+
+		diffs := test.Final.diff(cpu)
+		if m := cpu.Cycles / 4; m != uint64(len(test.Cycles)) {
+			diffs = append(diffs, fmt.Sprintf("M-cycles=%d want %d", m, len(test.Cycles)))
+		}
+		for m, want := range test.Cycles {
+			var got *sm83Cycle
+			if m < len(bus.cycles) {
+				got = bus.cycles[m]
+			}
+			if got.String() != want.String() {
+				diffs = append(diffs, fmt.Sprintf("M%d %v want %v", m, got, want))
+			}
+		}
+		for _, extra := range bus.extra {
+			diffs = append(diffs, "extra access "+extra)
+		}
+		if len(diffs) > 0 {
+			if failed < 3 {
+				t.Errorf("%s: %s", test.Name, strings.Join(diffs, ", "))
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		t.Errorf("%d of %d vectors failed", failed, len(tests))
+	}
+}
+
+func TestSM83(t *testing.T) {
+	dir := os.Getenv("SM83_TESTS")
+	full := dir != ""
+	if !full {
+		dir = filepath.Join("testdata", "sm83")
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) == 0 {
+		t.Skipf("no SM83 test vectors in %s", dir)
+	}
+	sort.Strings(files)
+	if full {
+		if missing := sm83Missing(files); len(missing) > 0 {
+			t.Errorf("%d opcodes have no vectors in %s: %s", len(missing), dir, strings.Join(missing, ", "))
+		}
+	}
+
+	for _, path := range files {
+		path := path
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(strings.ToUpper(name), func(t *testing.T) {
+			t.Parallel()
+			runSM83File(t, path)
+		})
+	}
+}
+
+func sm83Missing(files []string) []string {
+	// Name the opcodes with no vector file, the prefix byte and the illegal opcodes have none
+	have := map[string]bool{}
+	for _, path := range files {
+		have[strings.TrimSuffix(filepath.Base(path), ".json")] = true
+	}
+	missing := []string{}
+	for op := 0; op < 0x100; op++ {
+		name := fmt.Sprintf("%02x", op)
+		if op != 0xCB && Operations[op].Mneumonic != "???" && !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	for op := 0; op < 0x100; op++ {
+		if name := fmt.Sprintf("cb %02x", op); !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,26 @@
+package hardware
+
+// SpeedSwitch is KEY1 on CGB models, bit 0 arms a switch that STOP performs
+type SpeedSwitch struct {
+	armed  bool
+	double bool
+}
+
+func (s *SpeedSwitch) Double() bool {
+	return s.double
+}
+
+func (s *SpeedSwitch) Read(addr uint16) byte {
+	b := byte(0x7e) // unused bits read as 1
+	if s.double {
+		b |= 0x80
+	}
+	if s.armed {
+		b |= 0x01
+	}
+	return b
+}
+
+func (s *SpeedSwitch) Write(addr uint16, b byte) {
+	s.armed = b&0x01 != 0 // the current speed only changes in STOP
+}
@@ -0,0 +1,94 @@
+package hardware
+
+const (
+	REG_TIMA = 0xFF05 // Timer counter
+	REG_TMA  = 0xFF06 // Timer modulo
+	REG_TAC  = 0xFF07 // Timer control
+)
+
+// Bit of the system counter whose falling edge clocks TIMA, for each TAC clock select
+var timer_bits = [4]uint{9, 3, 5, 7}
+
+// Timer is DIV's 16 bit system counter and TIMA, which counts on the falling edges of a counter bit
+type Timer struct {
+	cpu      *CPU
+	counter  uint16 // DIV is the high byte
+	tima     byte
+	tma      byte
+	tac      byte
+	overflow bool // TIMA overflowed during the last M-cycle
+}
+
+func NewTimer(cpu *CPU) *Timer {
+	// Create a timer handling DIV, TIMA, TMA and TAC on the CPU's bus, it is not clocked until attached
+	t := &Timer{cpu: cpu}
+	for _, addr := range []uint16{REG_DIV, REG_TIMA, REG_TMA, REG_TAC} {
+		cpu.Bus.MapIO(addr, t)
+	}
+	return t
+}
+
+func (t *Timer) signal() bool {
+	// Input of the falling edge detector that clocks TIMA
+	return t.tac&0x04 != 0 && t.counter>>timer_bits[t.tac&0x03]&1 != 0
+}
+
+func (t *Timer) update(change func()) {
+	// Apply a change to the counter or TAC, counting a TIMA tick if the signal falls
+	before := t.signal()
+	change()
+	if before && !t.signal() {
+		t.count()
+	}
+}
+
+func (t *Timer) count() {
+	t.tima++
+	t.overflow = t.tima == 0
+}
+
+func (t *Timer) SetDIV(div byte) {
+	// Set the counter as a boot ROM leaves it, bits below DIV cleared
+	t.counter = uint16(div) << 8
+}
+
+func (t *Timer) Read(addr uint16) byte {
+	switch addr {
+	case REG_DIV:
+		return byte(t.counter >> 8)
+	case REG_TIMA:
+		return t.tima
+	case REG_TMA:
+		return t.tma
+	}
+	return t.tac | 0xf8 // unused bits read as 1
+}
+
+func (t *Timer) Write(addr uint16, b byte) {
+	switch addr {
+	case REG_DIV:
+		t.update(func() { t.counter = 0 }) // any write clears the whole counter
+	case REG_TIMA:
+		t.tima = b
+		t.overflow = false // a write in the cycle after an overflow cancels the reload
+	case REG_TMA:
+		t.tma = b
+	case REG_TAC:
+		t.update(func() { t.tac = b & 0x07 })
+	}
+}
+
+func (t *Timer) Tick(t_states uint8) {
+	for ; t_states >= 4; t_states -= 4 {
+		if t.overflow {
+			t.overflow = false
+			t.tima = t.tma
+			t.cpu.Request_interrupt(INT_TIMER)
+		}
+		before := t.signal()
+		t.counter += 4
+		if before && !t.signal() {
+			t.count()
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"flag"
+	"go-boy/cartridge"
 	"go-boy/hardware"
 	op "go-boy/opcodes"
 	"log"
 	"os"
+	"os/signal"
 )
 
 func main() {
@@ -15,13 +18,11 @@ func main() {
 	// 	op.LD_A_B,           // loop: A <- B
 	// 	op.LDI_ADDR_HL_A,    // [HL++] <- A
 	// 	op.PREFIX, op.RLC_B, // Rotate Left B
-	// 	op.JR_NC_e8, 0xfb, // jump to loop
-	// 	op.STOP,
+	// 	op.JR_NC_e8, 0xfa, // jump to loop
 	// }
 	program := []byte{
 		op.LD_A_n8, 0x11,
 		op.LD_ADDR_a16_A, 0x0A, 0x00,
-		op.STOP,
 	}
 	// program := []byte{
 	// 	op.LD_HL_n16, 0x20, 0x00, // HL <- 2000
@@ -42,19 +43,84 @@ func main() {
 	// 	op.LDI_ADDR_HL_A,
 	// 	op.PREFIX, op.SET_7_A,
 	// 	op.LDI_ADDR_HL_A,
-	// 	op.STOP,
 	// }
-	cpu := hardware.GetCPU()
-	ram := hardware.GetBus()
+	model_name := flag.String("model", "DMG", "hardware model: DMG0, DMG, MGB, SGB, SGB2, CGB or AGB")
+	rom_path := flag.String("rom", "", "cartridge to run until interrupted, battery RAM is kept in a .sav file next to it")
+	flag.Parse()
+	model, err := hardware.ParseModel(*model_name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *rom_path != "" {
+		if err := run_cartridge(*rom_path, model); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cpu := hardware.NewCPUModel(model)
+	ram := cpu.Bus
 
 	ram.WriteBytes(program, 0x0000)
 	cpu.PC = 0x0000
-	cpu.Run()
+	if err := cpu.RunUntil(uint16(len(program))); err != nil {
+		log.Fatal(err)
+	}
 
 	ram_contents := ram.String()
-	err := os.WriteFile("ram.txt", []byte(ram_contents), 0644)
+	err = os.WriteFile("ram.txt", []byte(ram_contents), 0644)
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 }
+
+func run_cartridge(path string, model hardware.MODEL) error {
+	// Run a cartridge until Ctrl-C, flushing its save file on the way out
+	cart, err := cartridge.Open(path)
+	if err != nil {
+		return err
+	}
+	cpu := hardware.NewCPUModel(model)
+	cart.Insert(cpu.Bus)
+	cpu.Reset(model)
+	if cpu.Infrared != nil {
+		link := &hardware.SharedLink{} // the cartridge and the CGB port see each other's LED
+		cpu.Infrared.Link = link.Connect()
+		cart.SetInfrared(link.Connect())
+	}
+
+	if p, ok := cart.Mapper.(hardware.Peripheral); ok {
+		cpu.Attach(p) // mappers that keep time, the camera's captures
+	}
+
+	var save *cartridge.SaveFile
+	if cart.Header.Type.Battery {
+		if save, err = cartridge.OpenSave(cart, cartridge.SavePath(path)); err != nil {
+			return err
+		}
+		save.OnError = func(err error) { log.Print(err) }
+		cpu.Attach(save)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-interrupt:
+			cpu.Terminate()
+		case <-done:
+		}
+	}()
+
+	err = cpu.Run()
+	if save != nil {
+		if flush_err := save.Flush(); err == nil {
+			err = flush_err
+		}
+	}
+	return err
+}
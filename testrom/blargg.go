@@ -0,0 +1,131 @@
+package testrom
+
+import (
+	"errors"
+	"strings"
+
+	"go-boy/cartridge"
+	"go-boy/hardware"
+)
+
+// Blargg ROMs print over serial and keep a status at 0xA000, 0x80 running, 0x81 press reset or the
+// result, valid once 0xA001 holds DE B0 61, with the text from 0xA004
+const (
+	BLARGG_STATUS    = 0xA000
+	BLARGG_SIGNATURE = 0xA001
+	BLARGG_TEXT      = 0xA004
+	BLARGG_RUNNING   = 0x80
+	BLARGG_RESET     = 0x81
+)
+
+// The reset has to come at least 100 ms after the ROM asks for it
+const BLARGG_RESET_DELAY = 4194304 / 10
+
+var blargg_signature = []byte{0xDE, 0xB0, 0x61}
+
+// About 30 emulated seconds, enough for the full cpu_instrs suite
+const BLARGG_TIMEOUT = 30 * 4194304
+
+var ErrTimeout = errors.New("test ROM timed out")
+
+type Result struct {
+	Passed bool
+	Output string // serial output, or the text in cartridge RAM if nothing was printed
+	Cycles uint64 // T-states until the result was reported
+}
+
+func load(rom []byte) (*hardware.CPU, *cartridge.Cartridge, error) {
+	// Insert a cartridge image into a DMG that has just finished booting
+	cart, err := cartridge.Load(rom)
+	if err != nil {
+		return nil, nil, err
+	}
+	cpu := hardware.NewCPU()
+	cart.Insert(cpu.Bus)
+	cpu.Reset(cpu.Model) // boot state depends on the header checksum
+	return cpu, cart, nil
+}
+
+func press_reset(cpu *hardware.CPU, cart *cartridge.Cartridge) (*cartridge.Cartridge, error) {
+	// Reset the CPU and the mapper registers, cartridge RAM where the test keeps its progress survives
+	fresh, err := cartridge.Load(cart.ROM)
+	if err != nil {
+		return nil, err
+	}
+	copy(fresh.RAM, cart.RAM)
+	fresh.Insert(cpu.Bus)
+	cpu.Reset(cpu.Model)
+	return fresh, nil
+}
+
+func blargg_status(cpu *hardware.CPU) (byte, bool) {
+	// Return the status code if the signature marks the status block as valid
+	for i, b := range blargg_signature {
+		if cpu.Bus.Read(BLARGG_SIGNATURE+uint16(i)) != b {
+			return 0, false
+		}
+	}
+	return cpu.Bus.Read(BLARGG_STATUS), true
+}
+
+func blargg_text(cpu *hardware.CPU) (string, bool) {
+	// Return the status text once the memory mapped result is complete
+	if status, ok := blargg_status(cpu); !ok || status == BLARGG_RUNNING || status == BLARGG_RESET {
+		return "", false
+	}
+	text := []byte{}
+	for addr := uint16(BLARGG_TEXT); addr < 0xC000; addr++ {
+		b := cpu.Bus.Read(addr)
+		if b == 0 {
+			break
+		}
+		text = append(text, b)
+	}
+	return string(text), true
+}
+
+func RunBlargg(rom []byte, timeout uint64) (Result, error) {
+	// Run a Blargg test ROM until it reports "Passed" or "Failed" or the timeout in T-states
+	// elapses, pressing reset whenever it asks for it
+	cpu, cart, err := load(rom)
+	if err != nil {
+		return Result{}, err
+	}
+	serial := hardware.NewSerial(cpu)
+	cpu.Attach(serial)
+
+	printed := 0
+	reset_at := uint64(0) // when to press reset, 0 if the ROM has not asked
+	for cpu.Cycles < timeout {
+		if status, ok := blargg_status(cpu); !ok || status != BLARGG_RESET {
+			reset_at = 0
+		} else if reset_at == 0 {
+			reset_at = cpu.Cycles + BLARGG_RESET_DELAY
+		} else if cpu.Cycles >= reset_at {
+			if cart, err = press_reset(cpu, cart); err != nil {
+				return Result{Output: string(serial.Output), Cycles: cpu.Cycles}, err
+			}
+			reset_at = 0
+		}
+
+		if err := cpu.Step(); err != nil {
+			return Result{Output: string(serial.Output), Cycles: cpu.Cycles}, err
+		}
+
+		out := serial.Output
+		if len(out) != printed {
+			printed = len(out)
+			if s := string(out); strings.Contains(s, "Passed") || strings.Contains(s, "Failed") {
+				return Result{Passed: !strings.Contains(s, "Failed"), Output: s, Cycles: cpu.Cycles}, nil
+			}
+		}
+		if text, ok := blargg_text(cpu); ok {
+			if len(out) > 0 {
+				text = string(out)
+			}
+			passed := cpu.Bus.Read(BLARGG_STATUS) == 0
+			return Result{Passed: passed, Output: text, Cycles: cpu.Cycles}, nil
+		}
+	}
+	return Result{Output: string(serial.Output), Cycles: cpu.Cycles}, ErrTimeout
+}
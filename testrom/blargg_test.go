@@ -0,0 +1,156 @@
+package testrom
+
+import (
+	"errors"
+	"go-boy/cartridge"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Builds a 32 KiB ROM+RAM image that jumps from the entry point to code at 0x0150
+func testROM(code []byte, data []byte) []byte {
+	rom := make([]byte, 0x8000)
+	copy(rom[0x0100:], []byte{0x00, 0xc3, 0x50, 0x01}) // nop, jp 0x0150
+	rom[cartridge.ADDR_TYPE] = 0x08                    // ROM+RAM
+	rom[cartridge.ADDR_RAM_SIZE] = 0x02                // 8 KiB
+	rom[cartridge.ADDR_HEADER_CHECKSUM] = cartridge.HeaderChecksum(rom)
+	copy(rom[0x0150:], code)
+	copy(rom[0x0180:], data)
+	return rom
+}
+
+// Prints the zero terminated string at 0x0180 over serial, then spins forever
+var serialPrint = []byte{
+	0x21, 0x80, 0x01, // 0150: ld HL,0x0180
+	0x2a,       //       0153: ld A,[HL+]       ; loop
+	0xb7,       //       0154: or A
+	0x28, 0x0e, //       0155: jr Z,e8 (done)
+	0xe0, 0x01, //       0157: ldh [SB],A
+	0x3e, 0x81, //       0159: ld A,0x81
+	0xe0, 0x02, //       015B: ldh [SC],A
+	0xf0, 0x02, //       015D: ldh A,[SC]       ; wait
+	0xcb, 0x7f, //       015F: bit 7,A
+	0x20, 0xfa, //       0161: jr NZ,e8 (wait)
+	0x18, 0xee, //       0163: jr e8 (loop)
+	0x18, 0xfe, //       0165: jr e8 (done)
+}
+
+func TestBlarggSerial(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		text   string
+		passed bool
+	}{
+		{"cpu_instrs\n\nPassed\n", true},
+		{"01-special\n\nFailed #6\n", false},
+	} {
+		res, err := RunBlargg(testROM(serialPrint, append([]byte(tc.text), 0)), BLARGG_TIMEOUT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Passed != tc.passed || !strings.HasPrefix(tc.text, res.Output) {
+			t.Errorf("got passed=%v output %q, wanted passed=%v output %q", res.Passed, res.Output, tc.passed, tc.text)
+		}
+	}
+}
+
+func TestBlarggMemory(t *testing.T) {
+	t.Parallel()
+	// Write the status block a byte at a time, then store the result code
+	block := append([]byte{BLARGG_RUNNING}, blargg_signature...)
+	block = append(block, []byte("Failed\n")...)
+	block = append(block, 0)
+
+	code := []byte{0x21, 0x00, 0xa0} // ld HL,0xA000
+	for _, b := range block {
+		code = append(code, 0x3e, b, 0x22) // ld A,n8; ld [HL+],A
+	}
+	code = append(code, 0x3e, 0x01, 0xea, 0x00, 0xa0) // ld A,0x01; ld [0xA000],A
+	code = append(code, 0x18, 0xfe)                   // jr e8 (spin)
+
+	res, err := RunBlargg(testROM(code, nil), BLARGG_TIMEOUT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Passed || res.Output != "Failed\n" {
+		t.Errorf("got passed=%v output %q", res.Passed, res.Output)
+	}
+}
+
+func TestBlarggReset(t *testing.T) {
+	t.Parallel()
+	// The first run leaves a marker in cartridge RAM and asks for a reset, the second passes
+	store := func(code []byte, addr uint16, data []byte) []byte {
+		code = append(code, 0x21, byte(addr), byte(addr>>8)) // ld HL,addr
+		for _, b := range data {
+			code = append(code, 0x3e, b, 0x22) // ld A,n8; ld [HL+],A
+		}
+		return code
+	}
+	first := store(nil, 0xa010, []byte{0x42})
+	first = store(first, BLARGG_STATUS, []byte{BLARGG_RUNNING})
+	first = store(first, BLARGG_SIGNATURE, blargg_signature)
+	first = store(first, BLARGG_STATUS, []byte{BLARGG_RESET})
+	first = append(first, 0x18, 0xfe) // jr e8 (spin)
+	second := store(nil, BLARGG_TEXT, append([]byte("Passed\n"), 0))
+	second = store(second, BLARGG_STATUS, []byte{0x00})
+	second = append(second, 0x18, 0xfe)
+
+	code := []byte{0xfa, 0x10, 0xa0, 0xfe, 0x42} // ld A,[0xA010]; cp 0x42
+	code = append(code, 0x28, byte(len(first)))  // jr Z,e8 (second)
+	code = append(append(code, first...), second...)
+
+	res, err := RunBlargg(testROM(code, nil), BLARGG_TIMEOUT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Passed || res.Output != "Passed\n" || res.Cycles < BLARGG_RESET_DELAY {
+		t.Errorf("got passed=%v output %q after %d T-states, wanted a pass after the reset", res.Passed, res.Output, res.Cycles)
+	}
+}
+
+func TestBlarggTimeout(t *testing.T) {
+	t.Parallel()
+	res, err := RunBlargg(testROM([]byte{0x18, 0xfe}, nil), 4096)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got %v, wanted a timeout", err)
+	}
+	if res.Cycles < 4096 {
+		t.Errorf("stopped after %d T-states", res.Cycles)
+	}
+}
+
+// Runs every ROM in BLARGG_ROMS (default testdata/blargg), e.g. the cpu_instrs,
+// instr_timing and mem_timing individual test images
+func TestBlargg(t *testing.T) {
+	dir := os.Getenv("BLARGG_ROMS")
+	if dir == "" {
+		dir = filepath.Join("testdata", "blargg")
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.gb"))
+	if len(files) == 0 {
+		t.Skipf("no Blargg test ROMs in %s", dir)
+	}
+	sort.Strings(files)
+
+	for _, path := range files {
+		path := path
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".gb"), func(t *testing.T) {
+			t.Parallel()
+			rom, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := RunBlargg(rom, BLARGG_TIMEOUT)
+			if err != nil {
+				t.Fatalf("%v after %d T-states, output %q", err, res.Cycles, res.Output)
+			}
+			if !res.Passed {
+				t.Errorf("%s", res.Output)
+			}
+		})
+	}
+}
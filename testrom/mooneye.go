@@ -0,0 +1,49 @@
+package testrom
+
+import (
+	"fmt"
+
+	"go-boy/hardware"
+)
+
+// Mooneye ROMs end with ld B,B, leaving 3, 5, 8, 13, 21, 34 in B to L to pass or 0x42 to fail
+const MOONEYE_BREAKPOINT = 0x40 // ld B,B
+
+// About 120 emulated seconds, the slowest acceptance tests finish well within this
+const MOONEYE_TIMEOUT = 120 * 4194304
+
+var mooneye_pass = [6]uint8{3, 5, 8, 13, 21, 34}
+var mooneye_fail = [6]uint8{0x42, 0x42, 0x42, 0x42, 0x42, 0x42}
+
+func mooneye_registers(cpu *hardware.CPU) [6]uint8 {
+	return [6]uint8{cpu.B, cpu.C, cpu.D, cpu.E, cpu.H, cpu.L}
+}
+
+func RunMooneye(rom []byte, timeout uint64) (Result, error) {
+	// Run a Mooneye test ROM until it executes ld B,B or the timeout in T-states elapses
+	cpu, _, err := load(rom)
+	if err != nil {
+		return Result{}, err
+	}
+	cpu.Attach(hardware.NewSerial(cpu))
+
+	for cpu.Cycles < timeout {
+		if err := cpu.Step(); err != nil {
+			return Result{Cycles: cpu.Cycles}, err
+		}
+		if cpu.ExecInfo.Dispatch || cpu.ExecInfo.Opcode != MOONEYE_BREAKPOINT {
+			continue
+		}
+
+		regs := mooneye_registers(cpu)
+		out := fmt.Sprintf("B=%02X C=%02X D=%02X E=%02X H=%02X L=%02X", regs[0], regs[1], regs[2], regs[3], regs[4], regs[5])
+		switch regs {
+		case mooneye_pass:
+			return Result{Passed: true, Output: out, Cycles: cpu.Cycles}, nil
+		case mooneye_fail:
+			return Result{Output: out, Cycles: cpu.Cycles}, nil
+		}
+		return Result{Output: out, Cycles: cpu.Cycles}, fmt.Errorf("ld B,B at %04X with unexpected registers %s", cpu.ExecInfo.Address, out)
+	}
+	return Result{Cycles: cpu.Cycles}, ErrTimeout
+}
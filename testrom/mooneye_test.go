@@ -0,0 +1,76 @@
+package testrom
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Loads B, C, D, E, H and L with the given values and hits the ld B,B breakpoint
+func mooneyeROM(regs [6]uint8) []byte {
+	return testROM([]byte{
+		0x06, regs[0], // ld B,n8
+		0x0e, regs[1], // ld C,n8
+		0x16, regs[2], // ld D,n8
+		0x1e, regs[3], // ld E,n8
+		0x26, regs[4], // ld H,n8
+		0x2e, regs[5], // ld L,n8
+		0x40,       // ld B,B
+		0x18, 0xfe, // jr e8 (spin)
+	}, nil)
+}
+
+func TestMooneyeBreakpoint(t *testing.T) {
+	t.Parallel()
+	res, err := RunMooneye(mooneyeROM(mooneye_pass), MOONEYE_TIMEOUT)
+	if err != nil || !res.Passed {
+		t.Errorf("pass signature: got passed=%v %s, error %v", res.Passed, res.Output, err)
+	}
+	res, err = RunMooneye(mooneyeROM(mooneye_fail), MOONEYE_TIMEOUT)
+	if err != nil || res.Passed {
+		t.Errorf("fail signature: got passed=%v %s, error %v", res.Passed, res.Output, err)
+	}
+	if _, err = RunMooneye(mooneyeROM([6]uint8{1, 2, 3, 4, 5, 6}), MOONEYE_TIMEOUT); err == nil {
+		t.Errorf("unexpected registers were not reported")
+	}
+}
+
+// Runs every ROM under MOONEYE_ROMS (default testdata/mooneye) as a subtest named
+// after its path, e.g. acceptance/timer/div_write
+func TestMooneye(t *testing.T) {
+	dir := os.Getenv("MOONEYE_ROMS")
+	if dir == "" {
+		dir = filepath.Join("testdata", "mooneye")
+	}
+	files := []string{}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".gb" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) == 0 {
+		t.Skipf("no Mooneye test ROMs in %s", dir)
+	}
+
+	for _, path := range files {
+		path := path
+		name, _ := filepath.Rel(dir, path)
+		t.Run(strings.TrimSuffix(filepath.ToSlash(name), ".gb"), func(t *testing.T) {
+			t.Parallel()
+			rom, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := RunMooneye(rom, MOONEYE_TIMEOUT)
+			if err != nil {
+				t.Fatalf("%v after %d T-states", err, res.Cycles)
+			}
+			if !res.Passed {
+				t.Errorf("failed: %s", res.Output)
+			}
+		})
+	}
+}